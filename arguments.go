@@ -15,13 +15,16 @@ import (
 var (
 	f = flag.NewFlagSet("BPP", flag.ContinueOnError)
 
-	cmdVersion = "version"
-	cmdBuild   = "build"
-	cmdPack    = "pack"
-	cmdPublish = "publish"
-	cmdPump    = "pump"
-	cmdClean   = "clean"
-	cmdHelp    = "help"
+	cmdVersion   = "version"
+	cmdBuild     = "build"
+	cmdPack      = "pack"
+	cmdPublish   = "publish"
+	cmdPump      = "pump"
+	cmdChangelog = "changelog"
+	cmdValidate  = "validate"
+	cmdConfig    = "config"
+	cmdClean     = "clean"
+	cmdHelp      = "help"
 
 	usagePrefix = `Usage: bpp COMMAND [OPTIONS]
 COMMAND:
@@ -34,10 +37,22 @@ COMMAND:
                 (Options: config, release, module, version, local)
 
   publish       Publish packages to repository
-                (Options: config, module, version)
+                (Options: config, module, version, dry-run, json, lenient)
 
   pump          Increasing version of project
-                (Options: patch, release, skip-backward, git-branch)
+                (Options: patch, release, skip-backward, git-branch, auto, changelog, module, local-repo, push, resume,
+                 pre, hotfix)
+
+  changelog     Writing changelog of current version from git history since the latest version tag
+                (Options: config, version, module)
+
+  validate      Checking project config and module configs, problems are reported as file:line
+                (Options: config, json)
+
+  config show   Showing effective project config and module configs, secrets are masked
+                (Options: config, profile, resolved, json, version, git-branch)
+                Precedence is flag > env BPP_* > profile > Project.bpp > global, flags that override config
+                are only --version and --git-branch
 
   version       Showing version of bpp
 
@@ -49,7 +64,19 @@ Examples:
   bpp build --release --local  
   bpp package --release
   bpp publish
+  bpp publish --dry-run --json
   bpp pump --skip-backward --git-branch=develop    
+  bpp pump --auto
+  bpp pump --auto --changelog
+  bpp pump --module=lib --auto
+  bpp pump --local-repo --push
+  bpp pump --resume
+  bpp pump --pre=rc
+  bpp pump --hotfix --git-branch=1.4.x
+  bpp changelog
+  bpp validate
+  bpp build --profile=ci
+  bpp config show --profile=ci --resolved
 
 Options:
 `
@@ -57,6 +84,7 @@ Options:
 
 type Arguments struct {
 	Command      string
+	SubCommand   string
 	Version      string
 	Module       string
 	ConfigFile   string
@@ -66,6 +94,18 @@ type Arguments struct {
 	BuildRelease bool
 	BuildPath    bool
 	BuildNumber  int
+	DryRun       bool
+	JsonOutput   bool
+	Lenient      bool
+	AutoVersion  bool
+	Changelog    bool
+	LocalRepo    bool
+	Push         bool
+	Resume       bool
+	PreRelease   string
+	Hotfix       bool
+	Profile      string
+	Resolved     bool
 	SkipOption
 }
 
@@ -85,6 +125,18 @@ func readArguments() (arg Arguments, err error) {
 	f.StringVar(&arg.GitBranch, "git-branch", "", "branch that code will be pushed")
 	buildNumber := f.String("build-number", "", "build number")
 	f.BoolVar(&arg.SkipBackward, "skip-backward", false, "if true, then major version will be increased")
+	f.BoolVar(&arg.DryRun, "dry-run", false, "printing what would be published without publishing anything")
+	f.BoolVar(&arg.JsonOutput, "json", false, "printing output as json")
+	f.BoolVar(&arg.AutoVersion, "auto", false, "next version is chosen by conventional commits since the last version tag")
+	f.BoolVar(&arg.Changelog, "changelog", false, "changelog of released version is committed together with next version")
+	f.BoolVar(&arg.LocalRepo, "local-repo", false, "pump in repository of working directory instead of cloning remote repository")
+	f.BoolVar(&arg.Push, "push", false, "changes of local repository are pushed to remote after pumping")
+	f.BoolVar(&arg.Resume, "resume", false, "finishing pump which is interrupted, refs that are not pushed yet are pushed")
+	f.StringVar(&arg.PreRelease, "pre", "", "current version is tagged as the next pre-release of given identifier, e.g. rc")
+	f.BoolVar(&arg.Hotfix, "hotfix", false, "next patch is released on release branch given by --git-branch, then it is merged into main branch")
+	f.BoolVar(&arg.Lenient, "lenient", false, "unknown repo ids and missing artifacts are reported as warning instead of error")
+	f.StringVar(&arg.Profile, "profile", "", "profile which overlays project config and module configs, default is BPP_PROFILE")
+	f.BoolVar(&arg.Resolved, "resolved", false, "printing where each config value comes from")
 
 	f.Usage = func() {
 		_, _ = fmt.Fprint(f.Output(), usagePrefix)
@@ -97,8 +149,14 @@ func readArguments() (arg Arguments, err error) {
 	}
 
 	arg.Command = strings.TrimSpace(os.Args[1])
-	if len(os.Args) > 2 {
-		err = f.Parse(os.Args[2:])
+	flagArgs := os.Args[2:]
+	//command config has sub command, e.g. bpp config show
+	if arg.Command == cmdConfig && len(flagArgs) > 0 && !strings.HasPrefix(flagArgs[0], "-") {
+		arg.SubCommand = strings.TrimSpace(flagArgs[0])
+		flagArgs = flagArgs[1:]
+	}
+	if len(flagArgs) > 0 {
+		err = f.Parse(flagArgs)
 	}
 
 	if buildNumber != nil && strings.TrimSpace(*buildNumber) != ""{
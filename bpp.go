@@ -40,16 +40,31 @@ func run(ctx context.Context) error {
 			return err
 		}
 		return pump(ctx)
+	case cmdValidate:
+		return validate(ctx)
+	case cmdConfig:
+		return configCommand(ctx)
+	case cmdChangelog:
+		err := prepareConfig()
+		if err != nil {
+			return err
+		}
+		return changelog(ctx)
 	}
 	return fmt.Errorf("can recognize command %s", arg.Command)
 }
 
 func prepareConfig() error {
-	var err error
-	cfg, err = config.ReadProjectConfig(workDir, arg.ConfigFile)
+	r, err := resolveProjectConfig()
 	if err != nil {
-		return nil
+		return err
+	}
+	cfg = config.ProjectConfig{}
+	err = r.Decode(&cfg)
+	if err != nil {
+		return fmt.Errorf("unmarshal application config file get error %v", err)
 	}
+	config.SetSecretsConfig(cfg.Secrets)
 	//initializing version
 	//due to each module may have different label. Then it takes version without label here and let appending label
 	//is executed in each module
@@ -1,7 +1,6 @@
 package buildpack
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -19,13 +18,14 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-	"sync"
+	"time"
 )
 
 type BuildSupervisor struct {
 	Priority         int
 	BuildType        string
 	DevMode          bool
+	BuildInfo        config.BuildOutputInfo
 	Modules          []Module
 	BuildImage       string
 	Dockerfile       string
@@ -34,15 +34,19 @@ type BuildSupervisor struct {
 	core.DockerClient
 }
 
+func (b *BuildSupervisor) useDocker() bool {
+	return !arg.BuildLocal && instrument.IsDockerRequiredForBuild(b.BuildType)
+}
+
 func (b *BuildSupervisor) close() {
-	if arg.BuildLocal {
+	if !b.useDocker() {
 		return
 	}
 	b.DockerClient.Close()
 }
 
 func (b *BuildSupervisor) initDockerClient(ctx context.Context) error {
-	if arg.BuildLocal {
+	if !b.useDocker() {
 		return nil
 	}
 	log.Printf("[%s] initiating docker client", b.BuildType)
@@ -56,7 +60,7 @@ func (b *BuildSupervisor) initDockerClient(ctx context.Context) error {
 }
 
 func (b *BuildSupervisor) prepareDockerImageForBuilding(ctx context.Context) error {
-	if arg.BuildLocal {
+	if !b.useDocker() {
 		return nil
 	}
 	e := b.Modules[0]
@@ -109,7 +113,8 @@ func (b *BuildSupervisor) prepareDockerImageForBuilding(ctx context.Context) err
 		dir = strings.TrimSuffix(dir, "/")
 	}
 	_, cat := filepath.Split(dir)
-	b.BuildImage = strings.ToLower(fmt.Sprintf("%s_%s:%s", cat, name, buildVersion))
+	//images of all builders are prepared before modules are built, then builder type keeps their tags apart
+	b.BuildImage = strings.ToLower(fmt.Sprintf("%s_%s_%s:%s", cat, name, b.BuildType, buildVersion))
 	log.Printf("[%s] docker build image name = %s", b.BuildType, b.BuildImage)
 	//create image build option
 	_, dockerFileName := filepath.Split(b.Dockerfile)
@@ -245,16 +250,8 @@ func build(ctx context.Context) error {
 	if arg.BuildRelease || arg.BuildPath {
 		isReleased = true
 	}
-	err = config.WriteBuildOutputInfo(config.BuildOutputInfo{
-		Version:     buildVersion,
-		Release:     isReleased,
-		BuildNumber: arg.BuildNumber,
-	}, outputDir)
-	if err != nil {
-		return err
-	}
-
-	globalDockerConfig, err := config.ReadGlobalDockerConfig()
+	buildInfo := newBuildOutputInfo(isReleased)
+	err = config.WriteBuildOutputInfo(buildInfo, outputDir)
 	if err != nil {
 		return err
 	}
@@ -278,7 +275,7 @@ func build(ctx context.Context) error {
 	}
 
 	//build Dockerfile for each builder type
-	hosts, registries := aggregateDockerConfigInfo(globalDockerConfig)
+	hosts, registries := aggregateDockerConfigInfo(cfg.DockerConfig)
 	mSupervisors := make(map[string]*BuildSupervisor)
 	for _, module := range destModules {
 		supervisor, ok := mSupervisors[module.config.BuildConfig.Type]
@@ -287,6 +284,7 @@ func build(ctx context.Context) error {
 			supervisor = &BuildSupervisor{
 				BuildType:        module.config.BuildConfig.Type,
 				DevMode:          !isReleased,
+				BuildInfo:        buildInfo,
 				Modules:          make([]Module, 0),
 				Dockerfile:       "",
 				DockerHosts:      hosts,
@@ -317,106 +315,41 @@ func build(ctx context.Context) error {
 		}
 	}()
 
-	///utilities
-	type BuildStep struct {
-		Current int
-		Prev    int
-	}
-
-	var errOut bytes.Buffer
-	defer errOut.Reset()
-
-	findWaitGroup := func(step BuildStep, wgs map[int]*sync.WaitGroup) []*sync.WaitGroup {
-		if step.Current == step.Prev {
-			return []*sync.WaitGroup{
-				wgs[step.Current], new(sync.WaitGroup),
-			}
-		}
-		return []*sync.WaitGroup{
-			wgs[step.Current], wgs[step.Prev],
-		}
-	}
-
-	findStep := func(moduleId int, steps []BuildStep) (BuildStep, error) {
-		for _, step := range steps {
-			if step.Current == moduleId {
-				return step, nil
-			}
-		}
-		return BuildStep{}, fmt.Errorf("not found build step associated to id %d", moduleId)
-	}
-	//end of utilities
-
+	//preparing phase of build process is completed
 	for _, supervisor := range supervisors {
 		err = supervisor.prepareDockerImageForBuilding(ctx)
 		if err != nil {
 			return err
 		}
+	}
 
-		//preparing phase of build process is completed
-		modules := supervisor.Modules
-
-		currentId := modules[0].Id
-		steps := []BuildStep{
-			{currentId, currentId},
-		}
-		for _, module := range modules {
-			if module.Id > currentId {
-				steps = append(steps, BuildStep{
-					module.Id, currentId,
-				})
-				currentId = module.Id
-			}
-		}
-
-		waitGroups := make(map[int]*sync.WaitGroup)
-		waitGroups[-1] = new(sync.WaitGroup)
-		for _, step := range steps {
-			wg := new(sync.WaitGroup)
-			for _, module := range modules {
-				if module.Id == step.Current {
-					wg.Add(1)
-				}
-			}
-			waitGroups[step.Current] = wg
-		}
+	graph, err := newModuleGraph(destModules)
+	if err != nil {
+		return err
+	}
 
-		supervisorWaitGroup := new(sync.WaitGroup)
-		newContext, cancel := context.WithCancel(ctx)
-		for _, module := range modules {
-			supervisorWaitGroup.Add(1)
-			step, err := findStep(module.Id, steps)
-			if err != nil {
-				errOut.WriteString(fmt.Sprintf("[%s] is failure: %s\n", module.Name, err.Error()))
-				cancel()
-				break
-			}
-			wgs := findWaitGroup(step, waitGroups)
-			go func(c context.Context, cwg, pwg, swg *sync.WaitGroup, m Module, s *BuildSupervisor, err *bytes.Buffer) {
-				e := buildModule(c, pwg, m, *s)
-				if e != nil {
-					err.WriteString(fmt.Sprintf("[%s] is failure: %s\n", m.Name, e.Error()))
-					cancel()
-				}
-				cwg.Done()
-				swg.Done()
-			}(newContext, wgs[0], wgs[1], supervisorWaitGroup, module, supervisor, &errOut)
-		}
-		supervisorWaitGroup.Wait()
+	return graph.run(ctx, func(c context.Context, m Module) error {
+		return buildModule(c, m, *mSupervisors[m.config.BuildConfig.Type])
+	})
+}
 
-		if errOut.Len() > 0 {
-			return fmt.Errorf(errOut.String())
-		}
+//newBuildOutputInfo returns info of build which is started, dev version of modules is rendered from it
+func newBuildOutputInfo(release bool) config.BuildOutputInfo {
+	//git sha is optional, dev version template fails only if it needs git sha
+	sha, _ := core.HeadCommit(workDir)
+	return config.BuildOutputInfo{
+		Version:     buildVersion,
+		Release:     release,
+		BuildNumber: arg.BuildNumber,
+		GitSha:      sha,
+		Timestamp:   time.Now().UTC().Format(DevVersionTimestampLayout),
 	}
-
-	return nil
 }
 
-func buildModule(ctx context.Context, prevWg *sync.WaitGroup, module Module, supervisor BuildSupervisor) error {
-	prevWg.Wait()
-	if ctx.Err() != nil {
-		log.Printf("[%s] is aborted", module.Name)
-		return nil
+func buildModule(ctx context.Context, module Module, supervisor BuildSupervisor) error {
+	devVersion, err := module.devVersion(buildVersion, supervisor.BuildInfo)
+	if err != nil {
+		return err
 	}
 	log.Printf("[%s] start to build (build number = %d)", module.Name, arg.BuildNumber)
 	response := instrument.Build(ctx, instrument.BuildRequest{
@@ -425,7 +358,8 @@ func buildModule(ctx context.Context, prevWg *sync.WaitGroup, module Module, sup
 			OutputDir:     outputDir,
 			ShareDataDir:  arg.ShareData,
 			DevMode:       supervisor.DevMode,
-			Version:       buildVersion,
+			Version:       module.versionOf(buildVersion),
+			DevVersion:    devVersion,
 			ModulePath:    module.Path,
 			ModuleName:    module.Name,
 			ModuleOutputs: module.config.Output,
@@ -0,0 +1,79 @@
+package buildpack
+
+import (
+	"context"
+	"fmt"
+	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/utils"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func changelogModules() []core.ChangelogModule {
+	modules := make([]core.ChangelogModule, 0)
+	for _, m := range cfg.Modules {
+		modules = append(modules, core.ChangelogModule{
+			Name: m.Name,
+			Path: filepath.ToSlash(m.Path),
+		})
+	}
+	return modules
+}
+
+//generateChangelog renders commits of target between the previous version tag and head of repository as section of version,
+//then merges it into existing content of changelog
+func generateChangelog(gitClient *core.GitClient, target pumpTarget, version string, existing []byte) ([]byte, error) {
+	last, commits, err := gitClient.CommitsSinceLastVersion(target.TagPrefix, target.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read git history error %v", err)
+	}
+	previousVersion := ""
+	if last != nil {
+		previousVersion = last.String()
+	}
+	log.Printf("generate changelog of version %s from %d commit(s)", version, len(commits))
+	changelog, err := core.NewChangelog(version, previousVersion, commits, target.changelogModules())
+	if err != nil {
+		return nil, err
+	}
+	section, err := renderChangelog(gitClient, target, changelog)
+	if err != nil {
+		return nil, err
+	}
+	return core.MergeChangelog(existing, section), nil
+}
+
+//changelog writes changelog of current version into working directory without committing it
+func changelog(ctx context.Context) error {
+	gitClient := &core.GitClient{
+		GitOption: core.GitOption{
+			WorkDir: workDir,
+		},
+	}
+	err := gitClient.OpenLocal()
+	if err != nil {
+		return fmt.Errorf("open git repository error %v", err)
+	}
+	target, err := projectPumpTarget(&cfg)
+	if err != nil {
+		return err
+	}
+	if !utils.IsStringEmpty(arg.Module) {
+		target, err = modulePumpTarget(gitClient, &cfg, utils.Trim(arg.Module))
+		if err != nil {
+			return err
+		}
+	}
+	file := filepath.Join(workDir, filepath.FromSlash(target.ChangelogFile))
+	existing, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	data, err := generateChangelog(gitClient, target, target.Version, existing)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
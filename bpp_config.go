@@ -0,0 +1,198 @@
+package buildpack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/utils"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	subCmdShow = "show"
+	maskedText = "******"
+
+	//configPrecedence is printed with resolved config. Only --version and --git-branch are values of config,
+	//other options such as --release, --module or --local choose what is done rather than override config
+	configPrecedence = "flag (--version, --git-branch) > env BPP_* > profile > Project.bpp > global ~/.bpp/.config"
+)
+
+//secretKeys are keys of config whose values are masked when config is shown, references of secrets are kept
+var secretKeys = map[string]struct{}{
+	"password":     {},
+	"token":        {},
+	"access_token": {},
+	"passphrase":   {},
+}
+
+//resolveProjectConfig returns resolver of project config with layers by precedence flags > env > profile > project > global.
+//Flag layer has --version and --git-branch only since they are the options that override values of config
+func resolveProjectConfig() (*config.Resolver, error) {
+	r, err := config.NewProjectResolver(workDir, arg.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	r.AddEnv(os.Environ())
+	if !utils.IsStringEmpty(arg.Version) {
+		r.Add(fmt.Sprintf("%s --version", config.SourceFlag), config.NestedValue(arg.Version, "version"))
+	}
+	//branch of hotfix is release branch while branch of git config is still main branch for merging back
+	if !utils.IsStringEmpty(arg.GitBranch) && !arg.Hotfix {
+		r.Add(fmt.Sprintf("%s --git-branch", config.SourceFlag), config.NestedValue(arg.GitBranch, "git", "branch"))
+	}
+	return r, nil
+}
+
+type configSection struct {
+	Name    string
+	File    string
+	Values  map[string]interface{}
+	Origins []config.Origin
+}
+
+func configCommand(ctx context.Context) error {
+	switch arg.SubCommand {
+	case subCmdShow:
+		return showConfig(ctx)
+	}
+	return fmt.Errorf("can recognize config command %s", arg.SubCommand)
+}
+
+//showConfig prints effective project config and module configs, values are printed with their sources if --resolved is given
+func showConfig(ctx context.Context) error {
+	r, err := resolveProjectConfig()
+	if err != nil {
+		return err
+	}
+	var c config.ProjectConfig
+	err = r.Decode(&c)
+	if err != nil {
+		return fmt.Errorf("unmarshal application config file get error %v", err)
+	}
+	sections := []configSection{{
+		Name:    "project",
+		File:    config.ConfigProject,
+		Values:  r.Values(),
+		Origins: r.Origins(),
+	}}
+	for _, m := range c.Modules {
+		moduleDir := filepath.Join(workDir, m.Path)
+		if utils.IsNotExists(filepath.Join(moduleDir, config.ConfigModule)) {
+			continue
+		}
+		mr, err := config.NewModuleResolver(moduleDir)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, configSection{
+			Name:    m.Name,
+			File:    filepath.Join(m.Path, config.ConfigModule),
+			Values:  mr.Values(),
+			Origins: mr.Origins(),
+		})
+	}
+	for i := range sections {
+		sections[i].Values = maskSecrets(sections[i].Values, "").(map[string]interface{})
+		for j, o := range sections[i].Origins {
+			sections[i].Origins[j].Value = maskSecrets(o.Value, o.Path)
+		}
+	}
+
+	if arg.JsonOutput {
+		out := make(map[string]interface{})
+		out["profile"] = config.ActiveProfile()
+		if arg.Resolved {
+			out["precedence"] = configPrecedence
+		}
+		modules := make(map[string]interface{})
+		for i, s := range sections {
+			var v interface{} = s.Values
+			if arg.Resolved {
+				v = s.Origins
+			}
+			if i == 0 {
+				out[s.Name] = v
+				continue
+			}
+			modules[s.Name] = v
+		}
+		out["modules"] = modules
+		bytes, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	}
+
+	if !utils.IsStringEmpty(config.ActiveProfile()) {
+		fmt.Printf("# profile: %s\n", config.ActiveProfile())
+	}
+	for i, s := range sections {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("# %s\n", s.File)
+		if arg.Resolved && i == 0 {
+			fmt.Printf("# precedence: %s\n", configPrecedence)
+		}
+		if arg.Resolved {
+			for _, o := range s.Origins {
+				fmt.Printf("%s = %s (%s)\n", o.Path, formatConfigValue(o.Value), o.Source)
+			}
+			continue
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		err = enc.Encode(s.Values)
+		if err != nil {
+			return err
+		}
+		_ = enc.Close()
+	}
+	return nil
+}
+
+//maskSecrets returns copy of value in which secrets are masked, path is used to know key of value
+func maskSecrets(value interface{}, path string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = maskSecrets(item, fmt.Sprintf("%s.%s", path, key))
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			out = append(out, maskSecrets(item, path))
+		}
+		return out
+	case string:
+		key := path[strings.LastIndex(path, ".")+1:]
+		if _, ok := secretKeys[key]; ok && v != "" && !config.IsSecretReference(v) {
+			return maskedText
+		}
+	}
+	return value
+}
+
+func formatConfigValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		bytes, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(bytes)
+	}
+	return fmt.Sprint(value)
+}
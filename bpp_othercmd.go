@@ -29,7 +29,7 @@ func clean(ctx context.Context) error {
 
 	globalDockerConfig, _ := config.ReadGlobalDockerConfig()
 
-	hosts, registries := aggregateDockerConfigInfo(globalDockerConfig)
+	hosts, registries := aggregateDockerConfigInfo(globalDockerConfig.DockerConfig)
 	dockerClient, err := core.InitDockerClient(ctx, hosts)
 	if err != nil {
 		return err
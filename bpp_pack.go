@@ -24,6 +24,7 @@ type PackSupervisor struct {
 	Priority         int
 	PackType         string
 	DevMode          bool
+	BuildInfo        config.BuildOutputInfo
 	Modules          []Module
 	PackImage        string
 	Dockerfile       string
@@ -32,15 +33,19 @@ type PackSupervisor struct {
 	core.DockerClient
 }
 
+func (b *PackSupervisor) useDocker() bool {
+	return !arg.BuildLocal && instrument.IsDockerRequiredForPack(b.PackType)
+}
+
 func (b *PackSupervisor) close() {
-	if arg.BuildLocal {
+	if !b.useDocker() {
 		return
 	}
 	b.DockerClient.Close()
 }
 
 func (b *PackSupervisor) initDockerClient(ctx context.Context) error {
-	if arg.BuildLocal {
+	if !b.useDocker() {
 		return nil
 	}
 	log.Printf("[%s] initiating docker client", b.PackType)
@@ -54,7 +59,7 @@ func (b *PackSupervisor) initDockerClient(ctx context.Context) error {
 }
 
 func (b *PackSupervisor) prepareDockerImageForPacking(ctx context.Context) error {
-	if arg.BuildLocal {
+	if !b.useDocker() || !instrument.IsPackImageRequired(b.PackType) {
 		return nil
 	}
 	e := b.Modules[0]
@@ -104,7 +109,8 @@ func (b *PackSupervisor) prepareDockerImageForPacking(ctx context.Context) error
 		dir = strings.TrimSuffix(dir, "/")
 	}
 	_, cat := filepath.Split(dir)
-	b.PackImage = strings.ToLower(fmt.Sprintf("%s_%s:%s", cat, name, buildVersion))
+	//images of all packers are prepared before modules are packed, then packer type keeps their tags apart
+	b.PackImage = strings.ToLower(fmt.Sprintf("%s_%s_%s:%s", cat, name, b.PackType, buildVersion))
 	log.Printf("[%s] docker build image name = %s", b.PackType, b.PackImage)
 	//create image build option
 	_, dockerFileName := filepath.Split(b.Dockerfile)
@@ -236,17 +242,15 @@ func pack(ctx context.Context) error {
 	if arg.BuildRelease || arg.BuildPath {
 		isReleased = true
 	}
+	var buildInfo config.BuildOutputInfo
 	if utils.IsNotExists(filepath.Join(outputDir, config.OutputInfo)) {
-		err = config.WriteBuildOutputInfo(config.BuildOutputInfo{
-			Version:     buildVersion,
-			Release:     isReleased,
-			BuildNumber: arg.BuildNumber,
-		}, outputDir)
+		buildInfo = newBuildOutputInfo(isReleased)
+		err = config.WriteBuildOutputInfo(buildInfo, outputDir)
 		if err != nil {
 			return err
 		}
 	} else {
-		buildInfo, err := config.ReadBuildOutputInfo(outputDir)
+		buildInfo, err = config.ReadBuildOutputInfo(outputDir)
 		if err != nil {
 			return err
 		}
@@ -255,10 +259,6 @@ func pack(ctx context.Context) error {
 		arg.BuildNumber = buildInfo.BuildNumber
 	}
 
-	globalDockerConfig, err := config.ReadGlobalDockerConfig()
-	if err != nil {
-		return err
-	}
 	tempModules, err := prepareListModule()
 	if err != nil {
 		return err
@@ -279,13 +279,14 @@ func pack(ctx context.Context) error {
 
 	//build pack supervisors
 	mSupervisors := make(map[string]*PackSupervisor)
-	hosts, registries := aggregateDockerConfigInfo(globalDockerConfig)
+	hosts, registries := aggregateDockerConfigInfo(cfg.DockerConfig)
 	for _, module := range destModules {
 		supervisor, ok := mSupervisors[module.config.PackConfig.Type]
 		if !ok {
 			log.Printf("initiating pack supervisor for builder %s", module.config.PackConfig.Type)
 			supervisor = &PackSupervisor{
 				DevMode:          !isReleased,
+				BuildInfo:        buildInfo,
 				PackType:         module.config.PackConfig.Type,
 				Modules:          make([]Module, 0),
 				Dockerfile:       "",
@@ -322,33 +323,48 @@ func pack(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-		modules := supervisor.Modules
-		for _, module := range modules {
-			resp := instrument.Pack(ctx, instrument.PackRequest{
-				BaseProperties: instrument.BaseProperties{
-					WorkDir:       workDir,
-					OutputDir:     outputDir,
-					ShareDataDir:  arg.ShareData,
-					DevMode:       supervisor.DevMode,
-					Version:       buildVersion,
-					ModulePath:    module.Path,
-					ModuleName:    module.Name,
-					ModuleOutputs: module.config.Output,
-					LocalBuild:    arg.BuildLocal,
-					BuildNumber:   arg.BuildNumber,
-				},
-				PackerName:   module.config.PackConfig.Type,
-				DockerImage:  supervisor.PackImage,
-				DockerClient: supervisor.DockerClient,
-			})
-
-			if resp.Err != nil {
-				if resp.ErrStack != "" {
-					return fmtError(resp.Err, resp.ErrStack)
-				}
-				return resp.Err
-			}
+	}
+
+	graph, err := newModuleGraph(destModules)
+	if err != nil {
+		return err
+	}
+
+	return graph.run(ctx, func(c context.Context, m Module) error {
+		return packModule(c, m, *mSupervisors[m.config.PackConfig.Type])
+	})
+}
+
+func packModule(ctx context.Context, module Module, supervisor PackSupervisor) error {
+	devVersion, err := module.devVersion(buildVersion, supervisor.BuildInfo)
+	if err != nil {
+		return err
+	}
+	log.Printf("[%s] start to pack (build number = %d)", module.Name, arg.BuildNumber)
+	resp := instrument.Pack(ctx, instrument.PackRequest{
+		BaseProperties: instrument.BaseProperties{
+			WorkDir:       workDir,
+			OutputDir:     outputDir,
+			ShareDataDir:  arg.ShareData,
+			DevMode:       supervisor.DevMode,
+			Version:       module.versionOf(buildVersion),
+			DevVersion:    devVersion,
+			ModulePath:    module.Path,
+			ModuleName:    module.Name,
+			ModuleOutputs: module.config.Output,
+			LocalBuild:    arg.BuildLocal,
+			BuildNumber:   arg.BuildNumber,
+		},
+		PackerName:   module.config.PackConfig.Type,
+		DockerImage:  supervisor.PackImage,
+		DockerClient: supervisor.DockerClient,
+	})
+	if resp.Err != nil {
+		if resp.ErrStack != "" {
+			return fmtError(resp.Err, resp.ErrStack)
 		}
+		return resp.Err
 	}
+	log.Printf("[%s] has been packed successful", module.Name)
 	return nil
 }
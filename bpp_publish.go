@@ -2,10 +2,15 @@ package buildpack
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/locngoxuan/buildpack/builtin"
 	"github.com/locngoxuan/buildpack/config"
 	"github.com/locngoxuan/buildpack/instrument"
 	"github.com/locngoxuan/buildpack/utils"
+	"log"
+	"strings"
 )
 
 func publish(ctx context.Context) error {
@@ -51,58 +56,205 @@ func publish(ctx context.Context) error {
 		}
 	}
 
-	globalRepoConfig, err := config.ReadGlobalRepositoryConfig()
-	if err != nil {
-		return err
-	}
-
+	//repositories of global config are merged into project config by resolver
 	repositories := make(map[string]config.Repository)
-	for _, r := range globalRepoConfig.Repos {
-		repositories[r.Id] = r
-	}
 	for _, r := range cfg.RepoConfig {
 		repositories[r.Id] = r
 	}
 
+	hosts, registries := aggregateDockerConfigInfo(cfg.DockerConfig)
+	//registries are not aggregated in local mode, but their ids are still valid repo ids of docker publish type
+	registryIds := make(map[string]struct{})
+	for _, registry := range cfg.DockerConfig.Registries {
+		registryIds[registry.Id] = struct{}{}
+	}
+
+	tasks := make([]publishTask, 0)
+	problems := make([]string, 0)
 	for _, module := range modules {
+		devVersion, err := module.devVersion(buildInfo.Version, buildInfo)
+		if err != nil {
+			return err
+		}
 		for _, pc := range module.config.Publish {
 			if len(pc.RepoIds) == 0 {
+				problems = append(problems, fmt.Sprintf("[%s] publish type %s has no repo_ids", module.Name, pc.Type))
 				continue
 			}
 
 			selectedRepos := make(map[string]config.Repository)
+			unknownRepoIds := make([]string, 0)
 			for _, repoId := range pc.RepoIds {
 				r, ok := repositories[repoId]
 				if !ok {
+					_, found := registryIds[repoId]
+					if !found || !strings.EqualFold(pc.Type, builtin.DockerPublisherName) {
+						unknownRepoIds = append(unknownRepoIds, repoId)
+					}
 					continue
 				}
+				if utils.IsStringEmpty(r.GetChannel(buildInfo.Release).Address) {
+					problems = append(problems, fmt.Sprintf("[%s] %s of repo %s is empty",
+						module.Name, channelName(buildInfo.Release), repoId))
+				}
 				selectedRepos[repoId] = r
 			}
-			resp := instrument.PublishPackage(ctx, instrument.PublishRequest{
-				BaseProperties: instrument.BaseProperties{
-					WorkDir:       workDir,
-					OutputDir:     outputDir,
-					ShareDataDir:  arg.ShareData,
-					DevMode:       !buildInfo.Release,
-					Version:       buildInfo.Version,
-					ModulePath:    module.Path,
-					ModuleName:    module.Name,
-					ModuleOutputs: module.config.Output,
-					LocalBuild:    arg.BuildLocal,
-					BuildNumber:   buildInfo.BuildNumber,
-				},
-				Repositories: selectedRepos,
-				PublishConfig: config.PublishConfig{
-					Type:    pc.Type,
-					RepoIds: pc.RepoIds,
+			if len(unknownRepoIds) > 0 {
+				problems = append(problems, fmt.Sprintf("[%s] publish type %s refers to unknown repo ids: %s",
+					module.Name, pc.Type, strings.Join(unknownRepoIds, ", ")))
+			}
+			tasks = append(tasks, publishTask{
+				Module:         module,
+				UnknownRepoIds: unknownRepoIds,
+				Request: instrument.PublishRequest{
+					BaseProperties: instrument.BaseProperties{
+						WorkDir:       workDir,
+						OutputDir:     outputDir,
+						ShareDataDir:  arg.ShareData,
+						DevMode:       !buildInfo.Release,
+						Version:       module.versionOf(buildInfo.Version),
+						DevVersion:    devVersion,
+						ModulePath:    module.Path,
+						ModuleName:    module.Name,
+						ModuleOutputs: module.config.Output,
+						LocalBuild:    arg.BuildLocal,
+						BuildNumber:   buildInfo.BuildNumber,
+					},
+					Repositories:     selectedRepos,
+					DockerHosts:      hosts,
+					DockerRegistries: registries,
+					Lenient:          arg.Lenient,
+					PublishConfig: config.PublishConfig{
+						Type:    pc.Type,
+						RepoIds: pc.RepoIds,
+					},
 				},
 			})
-			if resp.Err != nil {
-				if resp.ErrStack != "" {
-					return fmtError(resp.Err, resp.ErrStack)
-				}
-				return resp.Err
+		}
+	}
+
+	plans := planPublishTasks(ctx, tasks)
+	for _, plan := range plans {
+		if plan.Error != "" {
+			problems = append(problems, fmt.Sprintf("[%s] publish type %s: %s", plan.Module, plan.Type, plan.Error))
+			continue
+		}
+		if !plan.Unsupported && len(plan.Items) == 0 {
+			problems = append(problems, fmt.Sprintf("[%s] publish type %s resolves no artifact", plan.Module, plan.Type))
+		}
+	}
+
+	if arg.DryRun {
+		err = printPublishPlan(plans)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(problems) > 0 {
+		if !arg.Lenient {
+			return fmt.Errorf("publish config is invalid (use --lenient to ignore)\n%s", strings.Join(problems, "\n"))
+		}
+		for _, problem := range problems {
+			log.Printf("WARNING: %s", problem)
+		}
+	}
+
+	if arg.DryRun {
+		return nil
+	}
+
+	for _, task := range tasks {
+		resp := instrument.PublishPackage(ctx, task.Request)
+		if resp.Err != nil {
+			if resp.ErrStack != "" {
+				return fmtError(resp.Err, resp.ErrStack)
 			}
+			return resp.Err
+		}
+	}
+	return nil
+}
+
+//publishTask is a publish config of module which is resolved against repositories
+type publishTask struct {
+	Module         Module
+	UnknownRepoIds []string
+	Request        instrument.PublishRequest
+}
+
+//publishPlan is what publish would do for a task, it is printed by publish --dry-run
+type publishPlan struct {
+	Module         string                   `json:"module"`
+	Type           string                   `json:"type"`
+	RepoIds        []string                 `json:"repo_ids"`
+	UnknownRepoIds []string                 `json:"unknown_repo_ids,omitempty"`
+	Items          []instrument.PublishItem `json:"items"`
+	Error          string                   `json:"error,omitempty"`
+	Unsupported    bool                     `json:"unsupported,omitempty"`
+}
+
+func channelName(release bool) string {
+	if release {
+		return "channel_rel"
+	}
+	return "channel_dev"
+}
+
+//planPublishTasks resolves what would be published by each task.
+//Error of plan is kept in plan, publisher which does not support planning has neither items nor error
+func planPublishTasks(ctx context.Context, tasks []publishTask) []publishPlan {
+	plans := make([]publishPlan, 0)
+	for _, task := range tasks {
+		plan := publishPlan{
+			Module:         task.Module.Name,
+			Type:           task.Request.Type,
+			RepoIds:        task.Request.RepoIds,
+			UnknownRepoIds: task.UnknownRepoIds,
+			Items:          make([]instrument.PublishItem, 0),
+		}
+		items, err := instrument.PlanPublish(ctx, task.Request)
+		if errors.Is(err, instrument.ErrPublishPlanNotSupported) {
+			plan.Unsupported = true
+		} else if err != nil {
+			plan.Error = err.Error()
+		} else {
+			plan.Items = items
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+func printPublishPlan(plans []publishPlan) error {
+	if arg.JsonOutput {
+		bytes, err := json.MarshalIndent(plans, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	}
+
+	for _, plan := range plans {
+		fmt.Printf("[%s] publish by %s to %s\n", plan.Module, plan.Type, strings.Join(plan.RepoIds, ", "))
+		if len(plan.UnknownRepoIds) > 0 {
+			fmt.Printf("  %s\n", utils.TextYello(fmt.Sprintf("unknown repo ids: %s", strings.Join(plan.UnknownRepoIds, ", "))))
+		}
+		if plan.Error != "" {
+			fmt.Printf("  %s\n", utils.TextRed(plan.Error))
+			continue
+		}
+		if plan.Unsupported {
+			fmt.Println("  publish plan is not supported")
+			continue
+		}
+		if len(plan.Items) == 0 {
+			fmt.Println("  nothing to publish")
+			continue
+		}
+		for _, item := range plan.Items {
+			fmt.Printf("  [%s] %s -> %s\n", item.RepoId, item.Source, item.Destination)
 		}
 	}
 	return nil
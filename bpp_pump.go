@@ -2,14 +2,252 @@ package buildpack
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/locngoxuan/buildpack/config"
 	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/instrument"
 	"github.com/locngoxuan/buildpack/utils"
 	"gopkg.in/yaml.v2"
 	"log"
+	"path"
+	"path/filepath"
+	"strings"
 )
 
+//pumpTarget is what is pumped, either the whole project or a module which has its own version
+type pumpTarget struct {
+	//Name is empty if target is project
+	Name    string
+	Version string
+	//TagPrefix is prepended to version for naming tag, e.g. lib@1.3.0
+	TagPrefix string
+	//BranchPrefix is prepended to name of release branch, e.g. lib@1.3.x
+	BranchPrefix string
+	//Path of module in repository, only commits that change files under this path belong to module
+	Path          string
+	ChangelogFile string
+	//ChangelogTemplate is location of changelog template in repository, default template is used if it is empty
+	ChangelogTemplate string
+	//Modules whose version files are updated to next version of target
+	Modules []config.ModuleInfo
+	//writeVersion returns content of files which store next version
+	writeVersion func(gitClient *core.GitClient, nextVer string) (map[string][]byte, error)
+}
+
+func (t pumpTarget) tagName(version string) string {
+	return fmt.Sprintf("%s%s", t.TagPrefix, version)
+}
+
+func (t pumpTarget) tagMessage(version string) (string, error) {
+	return renderText("tag message", cfg.GitConfig.TagMessage, DefaultTagMessageTemplate, GitTemplate{
+		Version: version,
+		Module:  t.Name,
+	})
+}
+
+func (t pumpTarget) commitMessage(nextVer, releasedVer string) (string, error) {
+	return renderText("commit message", cfg.GitConfig.CommitMessage, DefaultCommitMessageTemplate, GitTemplate{
+		Version:         nextVer,
+		PreviousVersion: releasedVer,
+		Module:          t.Name,
+	})
+}
+
+//tagPrefix renders tag name template without version, template must end with version
+//then tags of previous versions can be recognized by the prefix
+func tagPrefix(module string) (string, error) {
+	const marker = "\x00"
+	name, err := renderText("tag name", cfg.GitConfig.TagName, DefaultTagNameTemplate, GitTemplate{
+		Version: marker,
+		Module:  module,
+	})
+	if err != nil {
+		return "", err
+	}
+	if strings.Count(name, marker) != 1 || !strings.HasSuffix(name, marker) {
+		return "", fmt.Errorf("tag name template must end with {{.Version}}")
+	}
+	return strings.TrimSuffix(name, marker), nil
+}
+
+func (t pumpTarget) changelogModules() []core.ChangelogModule {
+	if t.Name == "" {
+		return changelogModules()
+	}
+	return []core.ChangelogModule{
+		{
+			Name: t.Name,
+			Path: t.Path,
+		},
+	}
+}
+
+//projectPumpTarget returns target of project, project config is read from the repository being pumped
+//then concurrent changes of remote are kept when version is written
+func projectPumpTarget(project *config.ProjectConfig) (pumpTarget, error) {
+	version := utils.Trim(arg.Version)
+	if utils.IsStringEmpty(version) {
+		version = utils.Trim(project.Version)
+	}
+	prefix, err := tagPrefix("")
+	if err != nil {
+		return pumpTarget{}, err
+	}
+	return pumpTarget{
+		Version:           version,
+		TagPrefix:         prefix,
+		ChangelogFile:     project.Changelog.ChangelogFile(),
+		ChangelogTemplate: project.Changelog.Template,
+		Modules:           project.Modules,
+		writeVersion: func(gitClient *core.GitClient, nextVer string) (map[string][]byte, error) {
+			project.Version = nextVer
+			bytes, err := yaml.Marshal(project)
+			if err != nil {
+				return nil, fmt.Errorf("marshal data error %v", err)
+			}
+			return map[string][]byte{
+				config.ConfigProject: bytes,
+			}, nil
+		},
+	}, nil
+}
+
+//modulePumpTarget returns target of module which is independently versioned.
+//Version is stored either in project config or in module config, and next version is written back to the same place
+func modulePumpTarget(gitClient *core.GitClient, project *config.ProjectConfig, name string) (pumpTarget, error) {
+	index := -1
+	for i, m := range project.Modules {
+		if m.Name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return pumpTarget{}, fmt.Errorf("module %s not found", name)
+	}
+	info := project.Modules[index]
+	modulePath := strings.Trim(path.Clean(filepath.ToSlash(info.Path)), "/")
+	prefix, err := tagPrefix(info.Name)
+	if err != nil {
+		return pumpTarget{}, err
+	}
+	target := pumpTarget{
+		Name:              info.Name,
+		TagPrefix:         prefix,
+		BranchPrefix:      fmt.Sprintf("%s@", info.Name),
+		Path:              modulePath,
+		ChangelogFile:     path.Join(modulePath, project.Changelog.ChangelogFile()),
+		ChangelogTemplate: project.Changelog.Template,
+		Modules:           []config.ModuleInfo{info},
+	}
+
+	if !utils.IsStringEmpty(info.Version) {
+		target.Version = utils.Trim(info.Version)
+		target.writeVersion = func(gitClient *core.GitClient, nextVer string) (map[string][]byte, error) {
+			project.Modules[index].Version = nextVer
+			bytes, err := yaml.Marshal(project)
+			if err != nil {
+				return nil, fmt.Errorf("marshal data error %v", err)
+			}
+			return map[string][]byte{
+				config.ConfigProject: bytes,
+			}, nil
+		}
+		return target, nil
+	}
+
+	//module config is read from repository and updated as a yaml document, then other properties are kept as they are
+	moduleFile := path.Join(modulePath, config.ConfigModule)
+	data, err := gitClient.ReadFile(moduleFile)
+	if err != nil {
+		return pumpTarget{}, err
+	}
+	var doc yaml.MapSlice
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return pumpTarget{}, fmt.Errorf("unmarshal %s get error %v", moduleFile, err)
+	}
+	for _, item := range doc {
+		if item.Key == "version" {
+			target.Version = utils.Trim(fmt.Sprintf("%v", item.Value))
+		}
+	}
+	if utils.IsStringEmpty(target.Version) {
+		return pumpTarget{}, fmt.Errorf("module %s does not have its own version", name)
+	}
+	target.writeVersion = func(gitClient *core.GitClient, nextVer string) (map[string][]byte, error) {
+		for i := range doc {
+			if doc[i].Key == "version" {
+				doc[i].Value = nextVer
+			}
+		}
+		bytes, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("marshal data error %v", err)
+		}
+		return map[string][]byte{
+			moduleFile: bytes,
+		}, nil
+	}
+	return target, nil
+}
+
+//versionFiles returns content of version files of modules, they are read from repository and updated to next version.
+//If target is project, modules which have their own version are skipped
+func (t pumpTarget) versionFiles(gitClient *core.GitClient, nextVer string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, m := range t.Modules {
+		modulePath := strings.Trim(path.Clean(filepath.ToSlash(m.Path)), "/")
+		moduleFile := path.Join(modulePath, config.ConfigModule)
+		data, err := gitClient.ReadFile(moduleFile)
+		if err != nil {
+			return nil, fmt.Errorf("read %s get error %v", moduleFile, err)
+		}
+		if data == nil {
+			continue
+		}
+		var moduleConfig config.ModuleConfig
+		err = yaml.Unmarshal(data, &moduleConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal %s get error %v", moduleFile, err)
+		}
+		if t.Name == "" && (!utils.IsStringEmpty(m.Version) || !utils.IsStringEmpty(moduleConfig.Version)) {
+			continue
+		}
+		for _, versionFile := range moduleConfig.VersionFiles {
+			name, err := instrument.VersionFileName(versionFile)
+			if err != nil {
+				return nil, fmt.Errorf("version file of module %s get error %v", m.Name, err)
+			}
+			file := path.Join(modulePath, filepath.ToSlash(name))
+			content, err := gitClient.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("read %s get error %v", file, err)
+			}
+			if content == nil {
+				return nil, fmt.Errorf("version file %s of module %s not found", file, m.Name)
+			}
+			content, err = instrument.UpdateVersion(instrument.VersionUpdateRequest{
+				VersionFile: versionFile,
+				WorkDir:     workDir,
+				ModuleName:  m.Name,
+				ModulePath:  m.Path,
+				Version:     nextVer,
+				Content:     content,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("update version of %s get error %v", file, err)
+			}
+			files[file] = content
+		}
+	}
+	return files, nil
+}
+
+//pumpPushAttempts is the maximum number of times that pump is applied on a fresh clone when push is rejected
+const pumpPushAttempts = 3
+
 func pump(ctx context.Context) error {
 	branchName := utils.ReadEnvVariableIfHas(cfg.GitConfig.Branch)
 	if !utils.IsStringEmpty(arg.GitBranch) {
@@ -21,69 +259,381 @@ func pump(ctx context.Context) error {
 		branchName = "master"
 	}
 
-	gitClient := &core.GitClient{
+	journal, err := readPumpJournal()
+	if err != nil {
+		return err
+	}
+	if arg.Resume {
+		if journal == nil {
+			return fmt.Errorf("there is no unfinished pump to resume")
+		}
+		return resumePump(ctx, journal)
+	}
+	if journal != nil {
+		return fmt.Errorf("pump of branch %s is not finished, run bpp pump --resume to finish it", journal.Branch)
+	}
+
+	//hotfix is pumped on release branch then merged into main branch
+	mainBranch := branchName
+	if arg.Hotfix {
+		mainBranch = utils.Trim(utils.ReadEnvVariableIfHas(cfg.GitConfig.Branch))
+		if utils.IsStringEmpty(mainBranch) {
+			mainBranch = "master"
+		}
+		err = validateHotfix(branchName, mainBranch)
+		if err != nil {
+			return err
+		}
+	}
+	if !utils.IsStringEmpty(arg.PreRelease) && arg.Changelog {
+		return fmt.Errorf("changelog is not written for pre-release")
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := pumpOnce(ctx, branchName, mainBranch)
+		if !errors.Is(err, core.ErrNonFastForward) {
+			return err
+		}
+		if arg.LocalRepo {
+			return fmt.Errorf("push is rejected, local repository must be updated from remote before pumping: %v", err)
+		}
+		if attempt >= pumpPushAttempts {
+			return fmt.Errorf("push is rejected %d times since branch %s keeps changing on remote: %v", attempt, branchName, err)
+		}
+		log.Printf("push is rejected since branch %s is changed on remote, retrying on a fresh clone (%d/%d)",
+			branchName, attempt+1, pumpPushAttempts)
+	}
+}
+
+func newPumpGitClient(branchName string) *core.GitClient {
+	return &core.GitClient{
 		GitOption: core.GitOption{
 			WorkDir:       workDir,
 			Branch:        branchName,
 			RemoteAddress: cfg.GitConfig.RemoteAddress,
 			GitCredential: cfg.GitConfig.GitCredential,
+			Author:        cfg.GitConfig.Author,
+			Signing:       cfg.GitConfig.Signing,
 		},
 	}
-	err := gitClient.CloneIntoMemory(ctx)
+}
+
+//pumpOnce tags, creates release branch and commits next version locally, then pushes them as steps of a journal.
+//Refs which are already pushed are deleted if a later step fails, then pump can be applied again on a fresh clone
+func pumpOnce(ctx context.Context, branchName, mainBranch string) error {
+	gitClient := newPumpGitClient(branchName)
+	err := openRepository(ctx, gitClient)
 	if err != nil {
-		return fmt.Errorf("clone error %v", err)
+		return err
 	}
+	//changes of local repository are pushed only if it is requested
+	push := !arg.LocalRepo || arg.Push
 
-	v, err := core.Parse(buildVersion)
+	data, err := gitClient.ReadFile(config.ConfigProject)
+	if err != nil {
+		return err
+	}
+	project, err := config.ParseProjectConfig(data)
+	if err != nil {
+		return err
+	}
+	target, err := projectPumpTarget(&project)
+	if err != nil {
+		return err
+	}
+	if !utils.IsStringEmpty(arg.Module) {
+		target, err = modulePumpTarget(gitClient, &project, utils.Trim(arg.Module))
+		if err != nil {
+			return err
+		}
+	}
+
+	v, err := core.Parse(target.Version)
 	if err != nil {
 		return fmt.Errorf("can not recognize version %v", err)
 	}
 
-	log.Printf("create tag of version %s", buildVersion)
-	err = gitClient.Tag(ctx, v.String())
+	if arg.AutoVersion {
+		var required bool
+		v, required, err = detectVersion(gitClient, target, v)
+		if err != nil {
+			return err
+		}
+		if !required {
+			log.Printf("there is no commit that requires a new version")
+			return nil
+		}
+	}
+	if arg.Hotfix {
+		releaseBranch := fmt.Sprintf("%s%s", target.BranchPrefix, v.MinorBranch())
+		if branchName != releaseBranch {
+			return fmt.Errorf("hotfix of version %s must be pumped on branch %s instead of %s", v.String(), releaseBranch, branchName)
+		}
+		v, err = hotfixVersion(gitClient, target, v)
+		if err != nil {
+			return err
+		}
+	}
+	if !utils.IsStringEmpty(arg.PreRelease) {
+		v, err = preReleaseVersion(gitClient, target, v, utils.Trim(arg.PreRelease))
+		if err != nil {
+			return err
+		}
+	}
+
+	//changelog is generated before tagging, otherwise there is no commit since the latest version tag
+	files := make(map[string][]byte)
+	if arg.Changelog {
+		existing, err := gitClient.ReadFile(target.ChangelogFile)
+		if err != nil {
+			return err
+		}
+		files[target.ChangelogFile], err = generateChangelog(gitClient, target, v.String(), existing)
+		if err != nil {
+			return err
+		}
+	}
+
+	releasedVer := v.String()
+	tag := target.tagName(releasedVer)
+	tagMsg, err := target.tagMessage(releasedVer)
+	if err != nil {
+		return err
+	}
+	base, err := gitClient.HeadHash()
+	if err != nil {
+		return err
+	}
+	log.Printf("create tag %s", tag)
+	err = gitClient.CreateTag(tag, tagMsg)
 	if err != nil {
 		return fmt.Errorf("tagging before pumping version error: %v", err)
 	}
+	journal := &pumpJournal{
+		LocalRepo:  arg.LocalRepo,
+		Branch:     gitClient.Branch,
+		Base:       base,
+		TagMessage: tagMsg,
+		Steps: []pumpStep{
+			{Kind: pumpStepTag, Name: tag},
+		},
+	}
+	//pre-release is only tagged, version of project stays as it is until it is released
+	if !utils.IsStringEmpty(arg.PreRelease) {
+		if !push {
+			return nil
+		}
+		err = journal.save()
+		if err != nil {
+			return fmt.Errorf("write pump journal get error %v", err)
+		}
+		return journal.execute(ctx, gitClient)
+	}
 
 	v.NextPatch()
-	if arg.BuildPath {
-		//if pump for patching then push new version then terminate
-		return updateVersion(ctx, v.String(), gitClient)
+	//if pump for patching or hotfix then new version is pushed without creating branch
+	if !arg.BuildPath && !arg.Hotfix {
+		//it it is pump of releasing, then an branch of 1.0.x must be created
+		if arg.BuildRelease {
+			branch := fmt.Sprintf("%s%s", target.BranchPrefix, v.MinorBranch())
+			log.Printf("create branch %s", branch)
+			err = gitClient.CreateBranch(branch)
+			if err != nil {
+				return err
+			}
+			journal.Steps = append(journal.Steps, pumpStep{Kind: pumpStepBranch, Name: branch})
+		}
+
+		if arg.SkipBackward {
+			//if new change breaks the concept
+			v.NextMajor()
+		} else {
+			v.NextMinor()
+		}
 	}
 
-	//it it is pump of releasing, then an branch of 1.0.x must be created
-	if arg.BuildRelease {
-		branch := v.MinorBranch()
-		err = gitClient.CreateNewBranch(branch)
+	journal.CommitMessage, err = updateVersion(v.String(), releasedVer, gitClient, target, files)
+	if err != nil {
+		return err
+	}
+	if !push {
+		return nil
+	}
+	journal.Files = files
+	journal.Commit, err = gitClient.HeadHash()
+	if err != nil {
+		return err
+	}
+	journal.Steps = append(journal.Steps, pumpStep{Kind: pumpStepCommit, Name: gitClient.Branch})
+	if arg.Hotfix {
+		journal.MergeMessage = fmt.Sprintf("merging hotfix %s into %s", releasedVer, mainBranch)
+		log.Printf("merge %s into %s", branchName, mainBranch)
+		err = gitClient.MergeInto(ctx, mainBranch, journal.fileNames(), journal.MergeMessage)
+		if err != nil {
+			return err
+		}
+		journal.Merge, err = gitClient.HeadHash()
 		if err != nil {
 			return err
 		}
+		journal.Steps = append(journal.Steps, pumpStep{Kind: pumpStepMerge, Name: mainBranch})
 	}
+	err = journal.save()
+	if err != nil {
+		return fmt.Errorf("write pump journal get error %v", err)
+	}
+	return journal.execute(ctx, gitClient)
+}
 
-	if arg.SkipBackward {
-		//if new change breaks the concept
-		v.NextMajor()
-	} else {
-		v.NextMinor()
+//updateVersion commits next version of target together with other files, e.g. changelog of the released version.
+//Message of commit is returned
+func updateVersion(nextVer, releasedVer string, gitClient *core.GitClient, target pumpTarget, files map[string][]byte) (string, error) {
+	log.Printf("next version is %s", nextVer)
+	versionFiles, err := target.writeVersion(gitClient, nextVer)
+	if err != nil {
+		return "", err
+	}
+	for file, data := range versionFiles {
+		files[file] = data
+	}
+	versionFiles, err = target.versionFiles(gitClient, nextVer)
+	if err != nil {
+		return "", err
+	}
+	for file, data := range versionFiles {
+		files[file] = data
+	}
+	msg, err := target.commitMessage(nextVer, releasedVer)
+	if err != nil {
+		return "", err
 	}
+	err = gitClient.WriteFiles(files, msg)
+	if err != nil {
+		return "", fmt.Errorf("write file error %v", err)
+	}
+	return msg, nil
+}
 
-	return updateVersion(ctx, v.String(), gitClient)
+func validateHotfix(branchName, mainBranch string) error {
+	switch {
+	case utils.IsStringEmpty(arg.GitBranch):
+		return fmt.Errorf("release branch of hotfix must be given by --git-branch, e.g. --git-branch=1.4.x")
+	case branchName == mainBranch:
+		return fmt.Errorf("hotfix must be pumped on release branch instead of %s", mainBranch)
+	case arg.LocalRepo:
+		return fmt.Errorf("hotfix can not be pumped on local repository")
+	case arg.AutoVersion:
+		return fmt.Errorf("version of hotfix is always a patch, it can not be chosen by --auto")
+	case !utils.IsStringEmpty(arg.PreRelease):
+		return fmt.Errorf("hotfix can not be a pre-release")
+	}
+	return nil
 }
 
-func updateVersion(ctx context.Context, nextVer string, gitClient *core.GitClient) error {
-	log.Printf("next version is %s", nextVer)
-	cfg.Version = nextVer
-	bytes, err := yaml.Marshal(cfg)
+//hotfixVersion returns next patch of the latest version which is released on release branch of version
+func hotfixVersion(gitClient *core.GitClient, target pumpTarget, v core.Version) (core.Version, error) {
+	versions, err := gitClient.Versions(target.TagPrefix)
+	if err != nil {
+		return v, fmt.Errorf("read version tags get error %v", err)
+	}
+	next := v
+	for _, released := range versions {
+		if released.Major != v.Major || released.Minor != v.Minor || released.IsPrerelease() {
+			continue
+		}
+		if released.Compare(next) >= 0 {
+			next = released
+			next.NextPatch()
+		}
+	}
+	return next, nil
+}
+
+//preReleaseVersion returns next pre-release of version with given identifier, e.g. 1.5.0-rc.1, 1.5.0-rc.2.
+//Version of project is not changed by pre-release, then the latest pre-release is found by tags
+func preReleaseVersion(gitClient *core.GitClient, target pumpTarget, v core.Version, id string) (core.Version, error) {
+	versions, err := gitClient.Versions(target.TagPrefix)
 	if err != nil {
-		return fmt.Errorf("marshal data error %v", err)
+		return v, fmt.Errorf("read version tags get error %v", err)
+	}
+	next := core.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	var latest *core.Version
+	for i := range versions {
+		if versions[i].Core() != next.Core() {
+			continue
+		}
+		if !versions[i].IsPrerelease() {
+			return v, fmt.Errorf("version %s is already released", next.Core())
+		}
+		if latest == nil || versions[i].Compare(*latest) > 0 {
+			latest = &versions[i]
+		}
+	}
+	if latest == nil {
+		next.Prerelease = []string{id, "1"}
+		return next, nil
+	}
+	next.Prerelease = append([]string{}, latest.Prerelease...)
+	next.NextPrerelease(id)
+	if next.Compare(*latest) <= 0 {
+		return v, fmt.Errorf("pre-release %s must be higher than the latest one %s", next.String(), latest.String())
+	}
+	return next, nil
+}
+
+//openRepository clones remote repository into memory, or opens repository of working directory if --local-repo is set.
+//Local repository must be clean and on the branch of pumping
+func openRepository(ctx context.Context, gitClient *core.GitClient) error {
+	if !arg.LocalRepo {
+		err := gitClient.CloneIntoMemory(ctx)
+		if err != nil {
+			return fmt.Errorf("clone error %v", err)
+		}
+		return nil
 	}
-	err = gitClient.WriteSingleFile(bytes, config.ConfigProject, fmt.Sprintf("increasing version to %s", nextVer))
+	branchName := gitClient.Branch
+	err := gitClient.OpenLocal()
 	if err != nil {
-		return fmt.Errorf("write file error %v", err)
+		return fmt.Errorf("open git repository error %v", err)
 	}
-	err = gitClient.Push(ctx)
+	clean, err := gitClient.IsClean()
 	if err != nil {
-		return fmt.Errorf("push error %v", err)
+		return err
+	}
+	if !clean {
+		return fmt.Errorf("worktree of %s has changes of tracked files, they must be committed or stashed before pumping (untracked files are ignored)", workDir)
+	}
+	//branch of pumping is --git-branch, git.branch of config or master, then checked out branch must be the same one
+	if gitClient.Branch != branchName {
+		return fmt.Errorf("working copy is on branch %s instead of %s", gitClient.Branch, branchName)
 	}
 	return nil
 }
+
+//detectVersion chooses version of releasing by conventional commits since the last version tag of target.
+//If there is no version tag yet, then current version of target is released.
+//False is returned if none of commits requires a new version
+func detectVersion(gitClient *core.GitClient, target pumpTarget, current core.Version) (core.Version, bool, error) {
+	last, commits, err := gitClient.CommitsSinceLastVersion(target.TagPrefix, target.Path)
+	if err != nil {
+		return current, false, fmt.Errorf("read git history error %v", err)
+	}
+	conventionalCommits := make([]core.ConventionalCommit, 0)
+	for _, commit := range commits {
+		c, ok := core.ParseConventionalCommit(commit.Hash.String(), commit.Message)
+		if !ok {
+			log.Printf("commit %s does not follow conventional commits, it is ignored", commit.Hash.String()[:7])
+			continue
+		}
+		conventionalCommits = append(conventionalCommits, c)
+	}
+	bump := core.DetectBump(conventionalCommits)
+	if last == nil {
+		log.Printf("version tag not found, %d commit(s) are released as %s", len(commits), current.String())
+		return current, true, nil
+	}
+	v := *last
+	v.Bump(bump)
+	log.Printf("found %d commit(s) since version %s, bump is %s", len(commits), last.String(), bump.String())
+	return v, bump != core.BumpNone, nil
+}
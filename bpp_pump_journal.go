@@ -0,0 +1,272 @@
+package buildpack
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/core"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	pumpJournalDir       = "pump"
+	pumpJournalExtension = ".journal"
+)
+
+const (
+	pumpStepTag    = "tag"
+	pumpStepBranch = "branch"
+	pumpStepCommit = "commit"
+	pumpStepMerge  = "merge"
+)
+
+//pumpStep is an update of a ref on remote
+type pumpStep struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	Done bool   `json:"done"`
+}
+
+//pumpJournal records ref updates of a pump. Tag and release branch are pushed before version commit,
+//then they are only refs that must be deleted if a later step fails, and version commit is never forced.
+//Once a commit is pushed, pump can only be finished by resuming it.
+//Journal is kept until all steps are done or rolled back, then an interrupted pump can be resumed
+type pumpJournal struct {
+	LocalRepo bool   `json:"local_repo"`
+	Branch    string `json:"branch"`
+	//Base is hash of commit which is released, tag and release branch point to it
+	Base string `json:"base"`
+	//Commit is hash of version commit, it is created again from files if it does not exist in repository
+	Commit        string            `json:"commit"`
+	TagMessage    string            `json:"tag_message"`
+	CommitMessage string            `json:"commit_message"`
+	Files         map[string][]byte `json:"files"`
+	//Merge is hash of merge commit of hotfix into main branch, it is created again if it does not exist in repository
+	Merge        string     `json:"merge,omitempty"`
+	MergeMessage string     `json:"merge_message,omitempty"`
+	Steps        []pumpStep `json:"steps"`
+}
+
+//pumpJournalPath returns ~/.bpp/pump/{hash of working directory}.journal. Journal is kept out of output directory
+//which is removed by build and clean, and out of working directory which must be clean for pumping in local repository
+func pumpJournalPath() (string, error) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.Abs(workDir)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(dir))
+	name := fmt.Sprintf("%x%s", sum[:8], pumpJournalExtension)
+	return filepath.Join(userHome, config.OutputDir, pumpJournalDir, name), nil
+}
+
+//readPumpJournal returns nil if there is no unfinished pump
+func readPumpJournal() (*pumpJournal, error) {
+	file, err := pumpJournalPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read pump journal get error %v", err)
+	}
+	var j pumpJournal
+	err = json.Unmarshal(data, &j)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal pump journal get error %v", err)
+	}
+	return &j, nil
+}
+
+func (j *pumpJournal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := pumpJournalPath()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0600)
+}
+
+func (j *pumpJournal) remove() error {
+	file, err := pumpJournalPath()
+	if err != nil {
+		return err
+	}
+	err = os.Remove(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+//prepare creates local object of step if it is missing, it happens when pump is resumed on a fresh clone
+func (j *pumpJournal) prepare(ctx context.Context, gitClient *core.GitClient, step pumpStep) error {
+	switch step.Kind {
+	case pumpStepTag:
+		exist, err := gitClient.TagExists(step.Name)
+		if err != nil || exist {
+			return err
+		}
+		return gitClient.CreateTag(step.Name, j.TagMessage)
+	case pumpStepBranch:
+		exist, err := gitClient.BranchExists(step.Name)
+		if err != nil || exist {
+			return err
+		}
+		return gitClient.CreateBranch(step.Name)
+	case pumpStepCommit:
+		head, err := gitClient.BranchHash(j.Branch)
+		if err != nil {
+			return err
+		}
+		if head == j.Commit {
+			return nil
+		}
+		if head != j.Base {
+			return fmt.Errorf("branch %s is at %s which is neither released commit nor version commit", j.Branch, head)
+		}
+		files := make(map[string][]byte)
+		for file, data := range j.Files {
+			files[file] = data
+		}
+		err = gitClient.WriteFiles(files, j.CommitMessage)
+		if err != nil {
+			return fmt.Errorf("write file error %v", err)
+		}
+		j.Commit, err = gitClient.HeadHash()
+		return err
+	case pumpStepMerge:
+		head, err := gitClient.BranchHash(step.Name)
+		if err == nil && head == j.Merge {
+			return nil
+		}
+		err = gitClient.MergeInto(ctx, step.Name, j.fileNames(), j.MergeMessage)
+		if err != nil {
+			return err
+		}
+		j.Merge, err = gitClient.HeadHash()
+		return err
+	}
+	return fmt.Errorf("can not recognize pump step %s", step.Kind)
+}
+
+//fileNames returns files of version commit, they are not merged since main branch has its own version
+func (j *pumpJournal) fileNames() []string {
+	files := make([]string, 0, len(j.Files))
+	for file := range j.Files {
+		files = append(files, file)
+	}
+	return files
+}
+
+func (j *pumpJournal) push(ctx context.Context, gitClient *core.GitClient, step pumpStep) error {
+	if step.Kind == pumpStepTag {
+		return gitClient.PushTag(ctx, step.Name)
+	}
+	return gitClient.PushBranch(ctx, step.Name)
+}
+
+//execute pushes steps which are not done yet, refs that are created by done steps are deleted if a step fails
+func (j *pumpJournal) execute(ctx context.Context, gitClient *core.GitClient) error {
+	for i := range j.Steps {
+		step := j.Steps[i]
+		if step.Done {
+			continue
+		}
+		err := j.prepare(ctx, gitClient, step)
+		if err == nil {
+			log.Printf("push %s %s", step.Kind, step.Name)
+			err = j.push(ctx, gitClient, step)
+		}
+		if err != nil {
+			return j.rollback(ctx, gitClient, fmt.Errorf("push %s %s error %w", step.Kind, step.Name, err))
+		}
+		j.Steps[i].Done = true
+		err = j.save()
+		if err != nil {
+			return fmt.Errorf("write pump journal get error %v", err)
+		}
+	}
+	return j.remove()
+}
+
+//rollback deletes refs that are created by done steps in reverse order, cause is returned if all of them are deleted.
+//Pushed commit can not be rolled back without forcing, then journal is kept for resuming
+func (j *pumpJournal) rollback(ctx context.Context, gitClient *core.GitClient, cause error) error {
+	for _, step := range j.Steps {
+		if step.Done && (step.Kind == pumpStepCommit || step.Kind == pumpStepMerge) {
+			_ = j.save()
+			return fmt.Errorf("%v, then pump can not be rolled back since %s %s is pushed. Run bpp pump --resume to finish the pump",
+				cause, step.Kind, step.Name)
+		}
+	}
+	for i := len(j.Steps) - 1; i >= 0; i-- {
+		step := j.Steps[i]
+		if !step.Done {
+			continue
+		}
+		var err error
+		switch step.Kind {
+		case pumpStepTag:
+			err = gitClient.DeleteRemoteTag(ctx, step.Name)
+		case pumpStepBranch:
+			err = gitClient.DeleteRemoteBranch(ctx, step.Name)
+		default:
+			continue
+		}
+		if err != nil {
+			_ = j.save()
+			return fmt.Errorf("%v, then rollback of %s %s error %v. Run bpp pump --resume to finish the pump",
+				cause, step.Kind, step.Name, err)
+		}
+		log.Printf("rollback: %s %s is deleted", step.Kind, step.Name)
+		j.Steps[i].Done = false
+	}
+	err := j.remove()
+	if err != nil {
+		return fmt.Errorf("%v, then remove pump journal error %v", cause, err)
+	}
+	return cause
+}
+
+//resumePump finishes pump which is recorded in journal, either on local repository or on a fresh clone
+func resumePump(ctx context.Context, j *pumpJournal) error {
+	gitClient := newPumpGitClient(j.Branch)
+	var err error
+	if j.LocalRepo {
+		err = gitClient.OpenLocal()
+		if err == nil && gitClient.Branch != j.Branch {
+			err = fmt.Errorf("working copy is on branch %s instead of %s", gitClient.Branch, j.Branch)
+		}
+	} else {
+		err = gitClient.CloneIntoMemory(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("open git repository error %v", err)
+	}
+	log.Printf("resume pump of branch %s", j.Branch)
+	err = j.execute(ctx, gitClient)
+	if errors.Is(err, core.ErrNonFastForward) {
+		return fmt.Errorf("branch %s is changed on remote, pump can not be resumed: %v", j.Branch, err)
+	}
+	return err
+}
@@ -0,0 +1,423 @@
+package buildpack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/locngoxuan/buildpack/builtin"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/instrument"
+	"github.com/locngoxuan/buildpack/utils"
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//diagnostic is a problem of configuration, line is 0 if problem is not at a specific line of file
+type diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (d diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+//configFile is a config file which is parsed as yaml node, then diagnostics can point to lines of file
+type configFile struct {
+	//Name is path of file relative to working directory
+	Name string
+	Data []byte
+	Root *yaml.Node
+}
+
+//lookup returns node at path of keys and indexes, e.g. modules.0.path.
+//The deepest existing node is returned if path does not exist, then a diagnostic still points to the nearest line
+func (f configFile) lookup(path ...string) *yaml.Node {
+	if f.Root == nil {
+		return nil
+	}
+	node := f.Root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, p := range path {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == p {
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			index, err := strconv.Atoi(p)
+			if err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+			}
+		}
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+	return node
+}
+
+type configValidator struct {
+	diagnostics []diagnostic
+}
+
+func (v *configValidator) report(file configFile, path []string, format string, args ...interface{}) {
+	line := 0
+	if node := file.lookup(path...); node != nil {
+		line = node.Line
+	}
+	v.reportAt(file.Name, line, format, args...)
+}
+
+func (v *configValidator) reportAt(file string, line int, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, diagnostic{
+		File:    file,
+		Line:    line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+var yamlUnknownFieldPattern = regexp.MustCompile(`^field (\S+) not found in type .*$`)
+
+//reportYamlError reports each error of decoding at its line
+func (v *configValidator) reportYamlError(file string, err error) {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+	for _, msg := range messages {
+		line := 0
+		if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+		if m := yamlUnknownFieldPattern.FindStringSubmatch(msg); m != nil {
+			msg = fmt.Sprintf("unknown key %s", m[1])
+		}
+		v.reportAt(file, line, "%s", strings.TrimPrefix(msg, "yaml: "))
+	}
+}
+
+//parse reads config file as yaml node, false is returned if file can not be parsed
+func (v *configValidator) parse(name string, data []byte) (configFile, bool) {
+	file := configFile{
+		Name: name,
+		Data: data,
+		Root: &yaml.Node{},
+	}
+	err := yaml.Unmarshal(data, file.Root)
+	if err != nil {
+		v.reportYamlError(name, err)
+		return file, false
+	}
+	return file, true
+}
+
+//validateSchema validates file against JSON Schema. Unknown keys and wrong types are not reported here since strict decoding reports them
+func (v *configValidator) validateSchema(file configFile, schema []byte) {
+	var doc interface{}
+	err := yaml.Unmarshal(file.Data, &doc)
+	if err != nil {
+		v.reportYamlError(file.Name, err)
+		return
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewGoLoader(doc))
+	if err != nil {
+		v.reportAt(file.Name, 0, "validate schema get error %v", err)
+		return
+	}
+	for _, e := range result.Errors() {
+		switch e.Type() {
+		case "additional_property_not_allowed", "invalid_type":
+			continue
+		case "condition_then", "condition_else":
+			//errors of the applied branch are reported by themselves
+			continue
+		}
+		path := make([]string, 0)
+		if e.Field() != gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+			path = strings.Split(e.Field(), ".")
+		}
+		v.report(file, path, "%s", e.Description())
+	}
+}
+
+//decodeStrict decodes file into out, keys that are not known by out are reported
+func (v *configValidator) decodeStrict(file configFile, out interface{}) {
+	d := yaml.NewDecoder(bytes.NewReader(file.Data))
+	d.KnownFields(true)
+	err := d.Decode(out)
+	if err != nil && err != io.EOF {
+		v.reportYamlError(file.Name, err)
+	}
+}
+
+//moduleDocument returns pointer to a struct that has the same keys as module config, while build and pack are configs of
+//their builder and packer. Keys of plugins are not known, then build and pack of external types are not decoded strictly
+func moduleDocument(buildType, packType string) interface{} {
+	t := reflect.TypeOf(config.ModuleConfig{})
+	fields := make([]reflect.StructField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		field.Anonymous = false
+		switch field.Type {
+		case reflect.TypeOf(config.BuildConfig{}):
+			field.Type = sectionType(buildType, instrument.NewBuildConfig)
+		case reflect.TypeOf(config.PackConfig{}):
+			field.Type = sectionType(packType, instrument.NewPackConfig)
+		}
+		fields = append(fields, field)
+	}
+	return reflect.New(reflect.StructOf(fields)).Interface()
+}
+
+func sectionType(typeName string, newConfig func(string) interface{}) reflect.Type {
+	if instrument.IsExternal(typeName) {
+		return reflect.TypeOf(map[string]interface{}{})
+	}
+	return reflect.TypeOf(newConfig(typeName)).Elem()
+}
+
+//checkType reports type which is neither registered nor an external type whose plugin exists in module
+func (v *configValidator) checkType(file configFile, path []string, kind, typeName, moduleDir string,
+	registered func(string) bool) {
+	if utils.IsStringEmpty(typeName) {
+		return
+	}
+	if instrument.IsExternal(typeName) {
+		pluginFile := instrument.PluginFile(moduleDir, typeName)
+		if utils.IsNotExists(pluginFile) {
+			v.report(file, path, "plugin %s of %s type %s not found", relativePath(pluginFile), kind, typeName)
+		}
+		return
+	}
+	if !registered(typeName) {
+		v.report(file, path, "unknown %s type %s", kind, typeName)
+	}
+}
+
+func relativePath(p string) string {
+	rel, err := filepath.Rel(workDir, p)
+	if err != nil {
+		return p
+	}
+	return filepath.ToSlash(rel)
+}
+
+//validateProject returns project config which is decoded strictly, it is nil if project config can not be parsed
+func (v *configValidator) validateProject() *config.ProjectConfig {
+	name := arg.ConfigFile
+	if utils.IsStringEmpty(name) {
+		name = filepath.Join(workDir, config.ConfigProject)
+	}
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		v.reportAt(relativePath(name), 0, "read project config get error %v", err)
+		return nil
+	}
+	file, ok := v.parse(relativePath(name), data)
+	if !ok {
+		return nil
+	}
+	v.validateSchema(file, config.ProjectSchema)
+	var project config.ProjectConfig
+	v.decodeStrict(file, &project)
+
+	if !utils.IsStringEmpty(project.Version) {
+		_, err = core.Parse(utils.Trim(project.Version))
+		if err != nil {
+			v.report(file, []string{"version"}, "version %s is invalid: %v", project.Version, err)
+		}
+	}
+	names := make(map[string]struct{})
+	for _, m := range project.Modules {
+		names[m.Name] = struct{}{}
+	}
+	seen := make(map[string]struct{})
+	for i, m := range project.Modules {
+		path := []string{"modules", strconv.Itoa(i)}
+		if _, ok := seen[m.Name]; ok {
+			v.report(file, append(path, "name"), "module %s is declared more than once", m.Name)
+		}
+		seen[m.Name] = struct{}{}
+		if !utils.IsStringEmpty(m.Version) {
+			_, err = core.Parse(utils.Trim(m.Version))
+			if err != nil {
+				v.report(file, append(path, "version"), "version %s of module %s is invalid: %v", m.Version, m.Name, err)
+			}
+		}
+		for j, dep := range m.DependsOn {
+			_, ok := names[dep]
+			switch {
+			case dep == m.Name:
+				v.report(file, append(path, "depends_on", strconv.Itoa(j)), "module %s depends on itself", m.Name)
+			case !ok:
+				v.report(file, append(path, "depends_on", strconv.Itoa(j)), "module %s depends on unknown module %s", m.Name, dep)
+			}
+		}
+		if utils.IsStringEmpty(m.Path) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(workDir, m.Path))
+		if err != nil || !info.IsDir() {
+			v.report(file, append(path, "path"), "path %s of module %s is not a directory", m.Path, m.Name)
+			continue
+		}
+		if utils.IsNotExists(filepath.Join(workDir, m.Path, config.ConfigModule)) {
+			v.report(file, append(path, "path"), "%s of module %s not found", config.ConfigModule, m.Name)
+		}
+	}
+	repoIds := make(map[string]struct{})
+	for i, r := range project.RepoConfig {
+		if _, ok := repoIds[r.Id]; ok {
+			v.report(file, []string{"repositories", strconv.Itoa(i), "id"}, "repository %s is declared more than once", r.Id)
+		}
+		repoIds[r.Id] = struct{}{}
+	}
+	return &project
+}
+
+//validateModule validates module config, repoIds are ids of repositories that modules can publish to,
+//registryIds are ids of docker registries which are only valid for docker publish type
+func (v *configValidator) validateModule(m config.ModuleInfo, repoIds, registryIds map[string]struct{}) {
+	moduleDir := filepath.Join(workDir, m.Path)
+	name := filepath.Join(moduleDir, config.ConfigModule)
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return
+	}
+	file, ok := v.parse(relativePath(name), data)
+	if !ok {
+		return
+	}
+	v.validateSchema(file, config.ModuleSchema)
+	var c config.ModuleConfig
+	err = yaml.Unmarshal(data, &c)
+	if err != nil {
+		return
+	}
+	v.decodeStrict(file, moduleDocument(c.BuildConfig.Type, c.PackConfig.Type))
+
+	if !utils.IsStringEmpty(c.Version) {
+		_, err = core.Parse(utils.Trim(c.Version))
+		if err != nil {
+			v.report(file, []string{"version"}, "version %s is invalid: %v", c.Version, err)
+		}
+	}
+	v.checkType(file, []string{"build", "type"}, "build", c.BuildConfig.Type, moduleDir, instrument.IsBuilderRegistered)
+	v.checkType(file, []string{"pack", "type"}, "pack", c.PackConfig.Type, moduleDir, instrument.IsPackerRegistered)
+	for i, p := range c.Publish {
+		path := []string{"publish", strconv.Itoa(i)}
+		v.checkType(file, append(path, "type"), "publish", p.Type, moduleDir, instrument.IsPublisherRegistered)
+		for j, repoId := range p.RepoIds {
+			if _, ok := repoIds[repoId]; ok {
+				continue
+			}
+			if _, ok := registryIds[repoId]; !ok || !strings.EqualFold(p.Type, builtin.DockerPublisherName) {
+				v.report(file, append(path, "repo_ids", strconv.Itoa(j)), "unknown repo id %s", repoId)
+			}
+		}
+	}
+	for i, f := range c.VersionFiles {
+		path := []string{"version_files", strconv.Itoa(i)}
+		v.checkType(file, append(path, "type"), "version file", f.Type, moduleDir, instrument.IsVersionUpdaterRegistered)
+		if instrument.IsExternal(f.Type) || !instrument.IsVersionUpdaterRegistered(f.Type) {
+			continue
+		}
+		fileName, err := instrument.VersionFileName(f)
+		if err != nil {
+			v.report(file, path, "%v", err)
+			continue
+		}
+		if utils.IsNotExists(filepath.Join(moduleDir, fileName)) {
+			v.report(file, append(path, "file"), "version file %s not found", filepath.ToSlash(filepath.Join(m.Path, fileName)))
+		}
+	}
+}
+
+//knownRepoIds returns ids of repositories and ids of docker registries of project and global config
+func knownRepoIds(project *config.ProjectConfig) (map[string]struct{}, map[string]struct{}) {
+	repoIds := make(map[string]struct{})
+	registryIds := make(map[string]struct{})
+	for _, r := range project.RepoConfig {
+		repoIds[r.Id] = struct{}{}
+	}
+	for _, r := range project.DockerConfig.Registries {
+		registryIds[r.Id] = struct{}{}
+	}
+	if c, err := config.ReadGlobalRepositoryConfig(); err == nil {
+		for _, r := range c.Repos {
+			repoIds[r.Id] = struct{}{}
+		}
+	}
+	if c, err := config.ReadGlobalDockerConfig(); err == nil {
+		for _, r := range c.Registries {
+			registryIds[r.Id] = struct{}{}
+		}
+	}
+	return repoIds, registryIds
+}
+
+//validate checks project config and config of its modules, all problems are printed before returning error
+func validate(ctx context.Context) error {
+	v := &configValidator{
+		diagnostics: make([]diagnostic, 0),
+	}
+	project := v.validateProject()
+	if project != nil {
+		repoIds, registryIds := knownRepoIds(project)
+		for _, m := range project.Modules {
+			if utils.IsStringEmpty(m.Path) {
+				continue
+			}
+			v.validateModule(m, repoIds, registryIds)
+		}
+	}
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		if v.diagnostics[i].File != v.diagnostics[j].File {
+			return v.diagnostics[i].File < v.diagnostics[j].File
+		}
+		return v.diagnostics[i].Line < v.diagnostics[j].Line
+	})
+
+	if arg.JsonOutput {
+		out, err := json.MarshalIndent(v.diagnostics, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, d := range v.diagnostics {
+			fmt.Println(d.String())
+		}
+	}
+	if len(v.diagnostics) > 0 {
+		return fmt.Errorf("%d problems are found in configuration", len(v.diagnostics))
+	}
+	return nil
+}
@@ -13,7 +13,6 @@ import (
 	"github.com/locngoxuan/buildpack/instrument"
 	"github.com/locngoxuan/buildpack/utils"
 	"gopkg.in/yaml.v2"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
@@ -33,22 +32,9 @@ type MvnConfig struct {
 }
 
 func ReadMvnConfig(moduleDir string) (c MvnConfig, err error) {
-	configFile := filepath.Join(moduleDir, config.ConfigModule)
-	_, err = os.Stat(configFile)
-	if os.IsNotExist(err) {
-		err = fmt.Errorf("build config file %s not found", configFile)
-		return
-	}
-
-	yamlFile, err := ioutil.ReadFile(configFile)
+	//module config is overlaid by active profile
+	tmp, err := config.ReadModuleDocument(moduleDir)
 	if err != nil {
-		err = fmt.Errorf("read build config file get error %v", err)
-		return
-	}
-	var tmp map[string]interface{}
-	err = yaml.Unmarshal(yamlFile, &tmp)
-	if err != nil {
-		err = fmt.Errorf("unmarshal build config file get error %v", err)
 		return
 	}
 
@@ -73,14 +59,9 @@ func mvnLocalBuild(ctx context.Context, req instrument.BuildRequest) instrument.
 	}
 	args := make([]string, 0)
 	args = append(args, "clean", "install")
-	label := mvnConfig.Label
-	if utils.Trim(label) == "" {
-		label = "SNAPSHOT"
-	}
-	ver := req.Version
+	ver := req.BuildVersion()
 	if req.DevMode {
 		args = append(args, "-U")
-		ver = fmt.Sprintf("%s-%s", req.Version, label)
 	}
 	args = append(args, fmt.Sprintf("-Drevision=%s", ver))
 	if len(mvnConfig.Options) > 0 {
@@ -167,15 +148,10 @@ func mvnBuild(ctx context.Context, req instrument.BuildRequest) instrument.Respo
 		return instrument.ResponseError(err)
 	}
 
-	label := mvnConfig.Label
-	if utils.Trim(label) == "" {
-		label = "SNAPSHOT"
-	}
-	ver := req.Version
+	ver := req.BuildVersion()
 	dockerCommandArg := make([]string, 0)
 	dockerCommandArg = append(dockerCommandArg, "mvn", "install")
 	if req.DevMode {
-		ver = fmt.Sprintf("%s-%s", req.Version, label)
 		dockerCommandArg = append(dockerCommandArg, "-U")
 	}
 	dockerCommandArg = append(dockerCommandArg, fmt.Sprintf("-Drevision=%s", ver))
@@ -8,7 +8,6 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/pkg/stdcopy"
-	"github.com/locngoxuan/buildpack/config"
 	"github.com/locngoxuan/buildpack/instrument"
 	"github.com/locngoxuan/buildpack/utils"
 	"log"
@@ -51,18 +50,7 @@ func npmCmd(ctx context.Context, cwd string, options []string) instrument.Respon
 }
 
 func npmLocalBuild(ctx context.Context, req instrument.BuildRequest) instrument.Response {
-	c, err := config.ReadModuleConfig(filepath.Join(req.WorkDir, req.ModulePath))
-	if err != nil {
-		return instrument.ResponseError(err)
-	}
-	label := c.Label
-	if utils.Trim(label) == "" {
-		label = "SNAPSHOT"
-	}
-	ver := req.Version
-	if req.DevMode {
-		ver = fmt.Sprintf("%s-%s", req.Version, label)
-	}
+	ver := req.BuildVersion()
 	log.Printf("[%s] workging dir: %s", req.ModuleName, req.WorkDir)
 	log.Printf("[%s] cwd option: %s", req.ModuleName, filepath.Join(req.WorkDir, req.ModulePath))
 
@@ -95,7 +83,7 @@ func npmLocalBuild(ctx context.Context, req instrument.BuildRequest) instrument.
 	//copy output
 	for _, moduleOutput := range req.ModuleOutputs {
 		dest := filepath.Join(req.OutputDir, req.ModuleName, moduleOutput)
-		err = os.MkdirAll(dest, 0755)
+		err := os.MkdirAll(dest, 0755)
 		if err != nil {
 			return instrument.ResponseError(err)
 		}
@@ -160,18 +148,7 @@ func npmBuild(ctx context.Context, req instrument.BuildRequest) instrument.Respo
 		})
 	}
 
-	c, err := config.ReadModuleConfig(filepath.Join(req.WorkDir, req.ModulePath))
-	if err != nil {
-		return instrument.ResponseError(err)
-	}
-	label := c.Label
-	if utils.Trim(label) == "" {
-		label = "SNAPSHOT"
-	}
-	ver := req.Version
-	if req.DevMode {
-		ver = fmt.Sprintf("%s-%s", req.Version, label)
-	}
+	ver := req.BuildVersion()
 	log.Printf("[%s] docker image: %s", req.ModuleName, req.DockerImage)
 	log.Printf("[%s] workging dir: %s", req.ModuleName, req.WorkDir)
 	log.Printf("[%s] prefix option: %s", req.ModuleName, req.ModulePath)
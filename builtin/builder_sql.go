@@ -1 +1,202 @@
 package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/locngoxuan/buildpack/instrument"
+	"github.com/locngoxuan/buildpack/utils"
+	"github.com/locngoxuan/sqlbundle"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SqlBuilderName = "sql"
+	sqlBundleDir   = "bundle"
+	sqlSourceDir   = "src"
+)
+
+//name of migration script follows sqlbundle convention: {yyyyMMddHHmmss}_{name}.sql
+var sqlScriptPattern = regexp.MustCompile(`^(\d{14})_([A-Za-z0-9][A-Za-z0-9_.\-]*)\.sql$`)
+
+type SqlScript struct {
+	Sequence string
+	Name     string
+	Path     string
+}
+
+func readSqlPackageJson(moduleDir string) (sqlbundle.PackageJSON, error) {
+	c, err := sqlbundle.ReadPackageJSON(filepath.Join(moduleDir, sqlbundle.PACKAGE_JSON))
+	if err != nil {
+		return c, err
+	}
+	if utils.IsStringEmpty(c.GroupId) {
+		return c, fmt.Errorf("package.json is malformed: missing group property")
+	}
+	if utils.IsStringEmpty(c.ArtifactId) {
+		return c, fmt.Errorf("package.json is malformed: missing artifact property")
+	}
+	return c, nil
+}
+
+//collectSqlScripts reads migration scripts of module then verifies their names, ordering and up/down sections
+func collectSqlScripts(sourceDir string) ([]SqlScript, error) {
+	entries, err := ioutil.ReadDir(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("read migration directory get error %v", err)
+	}
+	scripts := make([]SqlScript, 0)
+	sequences := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			return nil, fmt.Errorf("migration directory %s must not contain sub directory %s", sourceDir, entry.Name())
+		}
+		if filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		parts := sqlScriptPattern.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("migration script %s is malformed: name must be {yyyyMMddHHmmss}_{name}.sql", entry.Name())
+		}
+		_, err = time.Parse(sqlbundle.TIME_FORMAT, parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("migration script %s is malformed: %s is not a valid time sequence", entry.Name(), parts[1])
+		}
+		if other, ok := sequences[parts[1]]; ok {
+			return nil, fmt.Errorf("migration scripts %s and %s have same sequence %s", other, entry.Name(), parts[1])
+		}
+		sequences[parts[1]] = entry.Name()
+		script := SqlScript{
+			Sequence: parts[1],
+			Name:     parts[2],
+			Path:     filepath.Join(sourceDir, entry.Name()),
+		}
+		err = verifySqlScript(script.Path)
+		if err != nil {
+			return nil, fmt.Errorf("migration script %s is malformed: %v", entry.Name(), err)
+		}
+		scripts = append(scripts, script)
+	}
+	if len(scripts) == 0 {
+		return nil, fmt.Errorf("not found any migration script in %s", sourceDir)
+	}
+	sort.Slice(scripts, func(i, j int) bool {
+		return scripts[i].Sequence < scripts[j].Sequence
+	})
+	return scripts, nil
+}
+
+//verifySqlScript makes sure that up section is present, and both up and down sections are closed in right order
+func verifySqlScript(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	opened := ""
+	found := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "--") {
+			continue
+		}
+		cmd := strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		for _, section := range []string{"up", "down"} {
+			switch {
+			case strings.HasPrefix(cmd, fmt.Sprintf("+%s BEGIN", section)):
+				if opened != "" {
+					return fmt.Errorf("line %d: %s section begins before %s section ends", lineNumber, section, opened)
+				}
+				if found[section] {
+					return fmt.Errorf("line %d: %s section is declared more than once", lineNumber, section)
+				}
+				opened = section
+			case strings.HasPrefix(cmd, fmt.Sprintf("+%s END", section)):
+				if opened != section {
+					return fmt.Errorf("line %d: %s section ends without beginning", lineNumber, section)
+				}
+				opened = ""
+				found[section] = true
+			}
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return err
+	}
+	if opened != "" {
+		return fmt.Errorf("%s section is not closed", opened)
+	}
+	if !found["up"] {
+		return fmt.Errorf("missing up section")
+	}
+	return nil
+}
+
+func sqlBuild(ctx context.Context, req instrument.BuildRequest) instrument.Response {
+	moduleDir := filepath.Join(req.WorkDir, req.ModulePath)
+	ver := req.BuildVersion()
+
+	packageJson, err := readSqlPackageJson(moduleDir)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+
+	sourceDir := filepath.Join(moduleDir, sqlSourceDir)
+	log.Printf("[%s] collecting migration scripts in %s", req.ModuleName, sourceDir)
+	scripts, err := collectSqlScripts(sourceDir)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+
+	//prepare sqlbundle project that is used for packing
+	bundleDir := filepath.Join(req.OutputDir, req.ModuleName, sqlBundleDir)
+	err = os.RemoveAll(bundleDir)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	err = os.MkdirAll(filepath.Join(bundleDir, sqlSourceDir), 0755)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	for _, script := range scripts {
+		if ctx.Err() != nil {
+			return instrument.ResponseError(ctx.Err())
+		}
+		_, fileName := filepath.Split(script.Path)
+		err = utils.CopyFile(script.Path, filepath.Join(bundleDir, sqlSourceDir, fileName))
+		if err != nil {
+			return instrument.ResponseError(err)
+		}
+	}
+
+	packageJson.Version = ver
+	if packageJson.Dependencies == nil {
+		packageJson.Dependencies = make([]string, 0)
+	}
+	bytes, err := json.MarshalIndent(packageJson, "", "  ")
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(bundleDir, sqlbundle.PACKAGE_JSON), bytes, 0644)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	log.Printf("[%s] %d migration scripts of %s:%s:%s are ready", req.ModuleName, len(scripts),
+		packageJson.GroupId, packageJson.ArtifactId, ver)
+	return instrument.ResponseSuccess()
+}
@@ -5,17 +5,45 @@ import "github.com/locngoxuan/buildpack/instrument"
 func InitBuiltInFunction(){
 	instrument.RegisterBuildDockerImage(MvnBuilderName, defaultMvnDockerImage)
 	instrument.RegisterBuildFunction(MvnBuilderName, mvnBuild)
+	instrument.RegisterBuildConfig(MvnBuilderName, func() interface{} { return &MvnConfig{} })
 	instrument.RegisterBuildDockerImage(NpmBuilderName, defaultNodeLtsDockerImage)
 	instrument.RegisterBuildFunction(NpmBuilderName, npmBuild)
 	instrument.RegisterBuildDockerImage(YarnBuilderName, defaultNodeLtsDockerImage)
 	instrument.RegisterBuildFunction(YarnBuilderName, yarnBuild)
+	instrument.RegisterBuildWithoutDocker(SqlBuilderName)
+	instrument.RegisterBuildFunction(SqlBuilderName, sqlBuild)
 
 	instrument.RegisterPackDockerImage(NpmPackerName, defaultNodeLtsDockerImage)
 	instrument.RegisterPackFunction(NpmPackerName, npmPack)
 	instrument.RegisterPackDockerImage(YarnPackerName, defaultNodeLtsDockerImage)
 	instrument.RegisterPackFunction(YarnPackerName, yarnPack)
+	instrument.RegisterPackWithoutImage(DockerPackerName)
+	instrument.RegisterPackFunction(DockerPackerName, dockerPack)
+	instrument.RegisterPackConfig(DockerPackerName, func() interface{} { return &DockerPackConfig{} })
+	instrument.RegisterPackWithoutDocker(MvnPackerName)
+	instrument.RegisterPackFunction(MvnPackerName, mvnPack)
+	instrument.RegisterPackWithoutDocker(SqlPackerName)
+	instrument.RegisterPackFunction(SqlPackerName, sqlPack)
 
 	instrument.RegisterPublishFunction(ArtifactoryMvnPublisherName, publishMvnJarToArtifactory)
 	instrument.RegisterPublishFunction(ArtifactoryYarnPublisherName, publishYarnJarToArtifactory)
 	instrument.RegisterPublishFunction(ArtifactoryNpmPublisherName, publishYarnJarToArtifactory)
+	instrument.RegisterPublishFunction(DockerPublisherName, publishDockerImage)
+	instrument.RegisterPublishFunction(MvnPublisherName, publishMvnToRepository)
+	instrument.RegisterPublishFunction(NpmPublisherName, publishNpmToRegistry)
+
+	instrument.RegisterPublishPlanFunction(ArtifactoryMvnPublisherName, planMvnJarToArtifactory)
+	instrument.RegisterPublishPlanFunction(ArtifactoryYarnPublisherName, planYarnJarToArtifactory)
+	instrument.RegisterPublishPlanFunction(ArtifactoryNpmPublisherName, planYarnJarToArtifactory)
+	instrument.RegisterPublishPlanFunction(DockerPublisherName, planDockerImage)
+	instrument.RegisterPublishPlanFunction(MvnPublisherName, planMvnToRepository)
+	instrument.RegisterPublishPlanFunction(NpmPublisherName, planNpmToRegistry)
+
+	instrument.RegisterVersionUpdateFunction(PomVersionUpdaterName, updatePomVersion)
+	instrument.RegisterVersionFileName(PomVersionUpdaterName, pomFile)
+	instrument.RegisterVersionUpdateFunction(PackageJsonVersionUpdaterName, updatePackageJsonVersion)
+	instrument.RegisterVersionFileName(PackageJsonVersionUpdaterName, packageJsonFile)
+	instrument.RegisterVersionUpdateFunction(ChartVersionUpdaterName, updateChartVersion)
+	instrument.RegisterVersionFileName(ChartVersionUpdaterName, chartFile)
+	instrument.RegisterVersionUpdateFunction(RegexVersionUpdaterName, updateRegexVersion)
 }
@@ -0,0 +1,246 @@
+package builtin
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/instrument"
+	"github.com/locngoxuan/buildpack/utils"
+	"gopkg.in/yaml.v2"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	DockerPackerName  = "docker"
+	defaultDockerfile = "Dockerfile"
+)
+
+/**
+Example:
+
+pack:
+  type: docker
+  name: myorg/service
+  dockerfile: Dockerfile
+  build_args:
+    JAVA_OPTS: $JAVA_OPTS
+*/
+type DockerPackConfig struct {
+	config.PackConfig `yaml:",inline"`
+	Name              string            `yaml:"name,omitempty"`
+	Dockerfile        string            `yaml:"dockerfile,omitempty"`
+	BuildArgs         map[string]string `yaml:"build_args,omitempty"`
+}
+
+func ReadDockerPackConfig(moduleDir string) (c DockerPackConfig, err error) {
+	//module config is overlaid by active profile
+	tmp, err := config.ReadModuleDocument(moduleDir)
+	if err != nil {
+		return
+	}
+
+	out, err := yaml.Marshal(tmp["pack"])
+	if err != nil {
+		err = fmt.Errorf("docker pack config is malformed %v", err)
+		return
+	}
+
+	err = yaml.Unmarshal(out, &c)
+	if err != nil {
+		err = fmt.Errorf("unmarshal build config file get error %v", err)
+		return
+	}
+	if utils.IsStringEmpty(c.Dockerfile) {
+		c.Dockerfile = defaultDockerfile
+	}
+	return
+}
+
+//ImageName returns repository name of image without registry and tag
+func (c DockerPackConfig) ImageName(moduleName string) string {
+	if utils.IsStringEmpty(c.Name) {
+		return strings.ToLower(moduleName)
+	}
+	return strings.ToLower(utils.Trim(c.Name))
+}
+
+//dockerImageVersion returns tag of image, + of build metadata is not allowed in docker tag then it is replaced by _
+func dockerImageVersion(req instrument.BaseProperties) string {
+	return strings.ReplaceAll(req.BuildVersion(), "+", "_")
+}
+
+func dockerBuildArgs(c DockerPackConfig, req instrument.PackRequest, ver string) map[string]*string {
+	args := make(map[string]*string)
+	for k, v := range c.BuildArgs {
+		value := utils.ReadEnvVariableIfHas(v)
+		args[k] = &value
+	}
+	buildNumber := strconv.Itoa(req.BuildNumber)
+	args["VERSION"] = &ver
+	args["BUILD_NUMBER"] = &buildNumber
+	return args
+}
+
+//createDockerPackContext archives module directory as docker build context.
+//Outputs of build stage are put at same relative path as they are in module directory
+func createDockerPackContext(req instrument.PackRequest) (string, error) {
+	moduleDir := filepath.Join(req.WorkDir, req.ModulePath)
+	tarFile := filepath.Join(req.OutputDir, req.ModuleName, "docker-context.tar")
+	f, err := os.Create(tarFile)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	tw := tar.NewWriter(f)
+
+	entries := make(map[string]struct{})
+	addDir := func(root, prefix string, skipOutputDir bool) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			if rel == "." {
+				return nil
+			}
+			if skipOutputDir && rel == config.OutputDir {
+				return filepath.SkipDir
+			}
+			if !info.Mode().IsRegular() && !info.IsDir() {
+				return nil
+			}
+			name := filepath.ToSlash(filepath.Join(prefix, rel))
+			if _, ok := entries[name]; ok {
+				return nil
+			}
+			entries[name] = struct{}{}
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = name
+			err = tw.WriteHeader(header)
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = file.Close()
+			}()
+			_, err = io.Copy(tw, file)
+			return err
+		})
+	}
+
+	//outputs of build stage take precedence over the same files in module directory
+	for _, output := range req.ModuleOutputs {
+		src := filepath.Join(req.OutputDir, req.ModuleName, output)
+		if utils.IsNotExists(src) {
+			continue
+		}
+		err = addDir(src, output, false)
+		if err != nil {
+			return "", err
+		}
+	}
+	err = addDir(moduleDir, "", true)
+	if err != nil {
+		return "", err
+	}
+	err = tw.Close()
+	if err != nil {
+		return "", err
+	}
+	return tarFile, nil
+}
+
+func dockerLocalPack(ctx context.Context, req instrument.PackRequest, c DockerPackConfig, image, ver string) instrument.Response {
+	moduleDir := filepath.Join(req.WorkDir, req.ModulePath)
+	args := []string{"build", "-t", image, "-f", filepath.Join(moduleDir, c.Dockerfile)}
+	for k, v := range dockerBuildArgs(c, req, ver) {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", k, *v))
+	}
+	args = append(args, moduleDir)
+	log.Printf("[%s] docker command: docker %s", req.ModuleName, strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	var buf bytes.Buffer
+	defer func() {
+		buf.Reset()
+	}()
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err := cmd.Run()
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return instrument.ResponseError(err)
+		}
+		return instrument.ResponseErrorWithStack(err, buf.String())
+	}
+	return instrument.ResponseSuccess()
+}
+
+func dockerPack(ctx context.Context, req instrument.PackRequest) instrument.Response {
+	moduleDir := filepath.Join(req.WorkDir, req.ModulePath)
+	c, err := ReadDockerPackConfig(moduleDir)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	if utils.IsNotExists(filepath.Join(moduleDir, c.Dockerfile)) {
+		return instrument.ResponseError(fmt.Errorf("%s not found in module %s", c.Dockerfile, req.ModuleName))
+	}
+	ver := dockerImageVersion(req.BaseProperties)
+	image := fmt.Sprintf("%s:%s", c.ImageName(req.ModuleName), ver)
+	log.Printf("[%s] building docker image %s", req.ModuleName, image)
+	if req.LocalBuild {
+		return dockerLocalPack(ctx, req, c, image, ver)
+	}
+
+	tarFile, err := createDockerPackContext(req)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tarFile)
+	}()
+
+	response, err := req.DockerClient.BuildImageWithOpts(ctx, tarFile, types.ImageBuildOptions{
+		Remove:      true,
+		ForceRemove: true,
+		Tags:        []string{image},
+		Dockerfile:  filepath.ToSlash(c.Dockerfile),
+		BuildArgs:   dockerBuildArgs(c, req, ver),
+	})
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	str, err := core.DisplayDockerLog(response.Body)
+	if err != nil {
+		return instrument.ResponseErrorWithStack(err, str)
+	}
+	log.Printf("[%s] docker image %s has been built", req.ModuleName, image)
+	return instrument.ResponseSuccess()
+}
@@ -0,0 +1,217 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/instrument"
+	"github.com/locngoxuan/buildpack/utils"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	MvnPackerName   = "mvn"
+	mvnPackDir      = "pack"
+	mvnPackManifest = "manifest.yml"
+)
+
+//MvnPackage describes the normalized layout that is written by mvn packer at .bpp/{module}/pack
+type MvnPackage struct {
+	GroupId    string           `yaml:"group_id"`
+	ArtifactId string           `yaml:"artifact_id"`
+	Version    string           `yaml:"version"`
+	Packaging  string           `yaml:"packaging"`
+	Files      []MvnPackageFile `yaml:"files"`
+}
+
+type MvnPackageFile struct {
+	Name       string `yaml:"name"`
+	Classifier string `yaml:"classifier,omitempty"`
+	Extension  string `yaml:"extension"`
+	Md5        string `yaml:"md5"`
+	Sha1       string `yaml:"sha1"`
+}
+
+//ModulePath returns path of artifact in maven repository layout
+func (p MvnPackage) ModulePath() string {
+	return fmt.Sprintf("%s/%s/%s",
+		strings.ReplaceAll(p.GroupId, ".", "/"),
+		p.ArtifactId,
+		p.Version)
+}
+
+func ReadMvnPackage(packDir string) (p MvnPackage, err error) {
+	file := filepath.Join(packDir, mvnPackManifest)
+	if utils.IsNotExists(file) {
+		err = fmt.Errorf("mvn package not found at %s", packDir)
+		return
+	}
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		err = fmt.Errorf("read mvn package manifest get error %v", err)
+		return
+	}
+	err = yaml.Unmarshal(bytes, &p)
+	if err != nil {
+		err = fmt.Errorf("unmarshal mvn package manifest get error %v", err)
+		return
+	}
+	return
+}
+
+func writeMvnPackage(p MvnPackage, packDir string) error {
+	bytes, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(packDir, mvnPackManifest), bytes, 0644)
+}
+
+//findBuildOutput looks for a file in outputs of build stage
+func findBuildOutput(req instrument.PackRequest, name string) string {
+	for _, output := range req.ModuleOutputs {
+		file := filepath.Join(req.OutputDir, req.ModuleName, output, name)
+		if !utils.IsNotExists(file) {
+			return file
+		}
+	}
+	return ""
+}
+
+func mvnPack(ctx context.Context, req instrument.PackRequest) instrument.Response {
+	ver := req.BuildVersion()
+
+	//pom.xml may be copied into outputs of build stage, otherwise pom.xml of module is used
+	pomFile := findBuildOutput(req, "pom.xml")
+	if pomFile == "" {
+		pomFile = filepath.Join(req.WorkDir, req.ModulePath, "pom.xml")
+	}
+	pom, err := core.ReadPOM(pomFile)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	packaging := utils.Trim(pom.Classifier)
+	if packaging == "" {
+		packaging = "jar"
+	}
+
+	//when project uses ci-friendly version, finalName may still contain ${revision}
+	finalName := fmt.Sprintf("%s-%s", pom.ArtifactId, ver)
+	if !utils.IsStringEmpty(pom.Build.FinalName) {
+		finalName = strings.ReplaceAll(pom.Build.FinalName, "${revision}", ver)
+		finalName = strings.ReplaceAll(finalName, "${project.artifactId}", pom.ArtifactId)
+		finalName = strings.ReplaceAll(finalName, "${project.version}", ver)
+	}
+
+	p := MvnPackage{
+		GroupId:    pom.GroupId,
+		ArtifactId: pom.ArtifactId,
+		Version:    ver,
+		Packaging:  packaging,
+		Files:      make([]MvnPackageFile, 0),
+	}
+	if utils.IsStringEmpty(p.GroupId) || utils.IsStringEmpty(p.ArtifactId) {
+		return instrument.ResponseError(fmt.Errorf("%s is malformed: missing groupId or artifactId", pomFile))
+	}
+
+	packDir := filepath.Join(req.OutputDir, req.ModuleName, mvnPackDir)
+	err = os.RemoveAll(packDir)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	err = os.MkdirAll(packDir, 0755)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+
+	addFile := func(src, classifier, extension string) error {
+		name := fmt.Sprintf("%s-%s.%s", p.ArtifactId, p.Version, extension)
+		if classifier != "" {
+			name = fmt.Sprintf("%s-%s-%s.%s", p.ArtifactId, p.Version, classifier, extension)
+		}
+		dest := filepath.Join(packDir, name)
+		if extension == "pom" {
+			//version of pom is resolved at build time via -Drevision
+			content, err := ioutil.ReadFile(src)
+			if err != nil {
+				return err
+			}
+			content = []byte(strings.ReplaceAll(string(content), "${revision}", ver))
+			err = ioutil.WriteFile(dest, content, 0644)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := utils.CopyFile(src, dest)
+			if err != nil {
+				return err
+			}
+		}
+		md5, err := utils.SumContentMD5(dest)
+		if err != nil {
+			return err
+		}
+		sha1, err := utils.SumContentSHA1(dest)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(fmt.Sprintf("%s.md5", dest), []byte(md5), 0644)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(fmt.Sprintf("%s.sha1", dest), []byte(sha1), 0644)
+		if err != nil {
+			return err
+		}
+		log.Printf("[%s] packed %s", req.ModuleName, name)
+		p.Files = append(p.Files, MvnPackageFile{
+			Name:       name,
+			Classifier: classifier,
+			Extension:  extension,
+			Md5:        md5,
+			Sha1:       sha1,
+		})
+		return nil
+	}
+
+	err = addFile(pomFile, "", "pom")
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+
+	if packaging != "pom" {
+		mainName := fmt.Sprintf("%s.%s", finalName, packaging)
+		mainFile := findBuildOutput(req, mainName)
+		if mainFile == "" {
+			return instrument.ResponseError(fmt.Errorf("artifact %s not found in %v", mainName, req.ModuleOutputs))
+		}
+		err = addFile(mainFile, "", packaging)
+		if err != nil {
+			return instrument.ResponseError(err)
+		}
+		for _, classifier := range []string{"sources", "javadoc"} {
+			if ctx.Err() != nil {
+				return instrument.ResponseError(ctx.Err())
+			}
+			file := findBuildOutput(req, fmt.Sprintf("%s-%s.jar", finalName, classifier))
+			if file == "" {
+				continue
+			}
+			err = addFile(file, classifier, "jar")
+			if err != nil {
+				return instrument.ResponseError(err)
+			}
+		}
+	}
+
+	err = writeMvnPackage(p, packDir)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	return instrument.ResponseSuccess()
+}
@@ -0,0 +1,62 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"github.com/locngoxuan/buildpack/instrument"
+	"github.com/locngoxuan/buildpack/utils"
+	"github.com/locngoxuan/sqlbundle"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	SqlPackerName = "sql"
+	sqlOutputDir  = "output"
+)
+
+func sqlPack(ctx context.Context, req instrument.PackRequest) instrument.Response {
+	bundleDir := filepath.Join(req.OutputDir, req.ModuleName, sqlBundleDir)
+	if utils.IsNotExists(bundleDir) {
+		return instrument.ResponseError(fmt.Errorf("module %s must be built by %s builder before packing", req.ModuleName, SqlBuilderName))
+	}
+
+	//version of bundle was already decided in build stage
+	packageJson, err := readSqlPackageJson(bundleDir)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+
+	bundle, err := sqlbundle.NewSQLBundle(sqlbundle.Argument{
+		WorkDir: bundleDir,
+		Version: packageJson.Version,
+	})
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	log.Printf("[%s] bundling migration scripts of %s:%s:%s", req.ModuleName,
+		packageJson.GroupId, packageJson.ArtifactId, packageJson.Version)
+	err = bundle.Pack()
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	if ctx.Err() != nil {
+		return instrument.ResponseError(ctx.Err())
+	}
+
+	outputPackDir := filepath.Join(req.OutputDir, req.ModuleName, sqlOutputDir)
+	err = os.MkdirAll(outputPackDir, 0755)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	sourceFile := filepath.Join(bundle.BuildDir, fmt.Sprintf("%s.tar", packageJson.ArtifactId))
+	destFile := filepath.Join(outputPackDir, fmt.Sprintf("%s-%s.tar", packageJson.ArtifactId, packageJson.Version))
+	err = utils.CopyFile(sourceFile, destFile)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	_ = os.RemoveAll(bundle.BuildDir)
+	log.Printf("[%s] sql bundle is written to %s", req.ModuleName, destFile)
+	return instrument.ResponseSuccess()
+}
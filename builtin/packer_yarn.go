@@ -8,7 +8,6 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/pkg/stdcopy"
-	"github.com/locngoxuan/buildpack/config"
 	"github.com/locngoxuan/buildpack/core"
 	"github.com/locngoxuan/buildpack/instrument"
 	"github.com/locngoxuan/buildpack/utils"
@@ -24,18 +23,7 @@ const (
 )
 
 func yarnLocalPack(ctx context.Context, req instrument.PackRequest) instrument.Response {
-	c, err := config.ReadModuleConfig(filepath.Join(req.WorkDir, req.ModulePath))
-	if err != nil {
-		return instrument.ResponseError(err)
-	}
-	label := c.Label
-	if utils.Trim(label) == "" {
-		label = "SNAPSHOT"
-	}
-	ver := req.Version
-	if req.DevMode {
-		ver = fmt.Sprintf("%s-%s", req.Version, label)
-	}
+	ver := req.BuildVersion()
 	//should read current version from package.json here
 	cwd := filepath.Join(req.WorkDir, req.ModulePath)
 	packageJson, err := core.ReadPackageJson(filepath.Join(cwd, "package.json"))
@@ -82,25 +70,14 @@ func yarnPack(ctx context.Context, req instrument.PackRequest) instrument.Respon
 	if req.LocalBuild {
 		return yarnLocalPack(ctx, req)
 	}
-	c, err := config.ReadModuleConfig(filepath.Join(req.WorkDir, req.ModulePath))
-	if err != nil {
-		return instrument.ResponseError(err)
-	}
-	label := c.Label
-	if utils.Trim(label) == "" {
-		label = "SNAPSHOT"
-	}
-	ver := req.Version
-	if req.DevMode {
-		ver = fmt.Sprintf("%s-%s", req.Version, label)
-	}
+	ver := req.BuildVersion()
 	log.Printf("[%s] docker image: %s", req.ModuleName, req.DockerImage)
 	log.Printf("[%s] workging dir: %s", req.ModuleName, req.WorkDir)
 	log.Printf("[%s] cwd option: %s", req.ModuleName, req.ModulePath)
 
 	//prepare mount environment
 	mounts := make([]mount.Mount, 0)
-	err = os.MkdirAll(filepath.Join(req.OutputDir, req.ModuleName, nodeOutputDir), 0755)
+	err := os.MkdirAll(filepath.Join(req.OutputDir, req.ModuleName, nodeOutputDir), 0755)
 	if err != nil {
 		return instrument.ResponseError(err)
 	}
@@ -3,9 +3,15 @@ package builtin
 import (
 	"context"
 	"fmt"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/instrument"
+	"github.com/locngoxuan/buildpack/utils"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 type ArtifactoryPackage struct {
@@ -46,3 +52,67 @@ func uploadFile(ctx context.Context, param ArtifactoryPackage) error {
 	}
 	return nil
 }
+
+//selectedRepositories returns repositories of request in order of repo ids, unknown ids are not part of result
+func selectedRepositories(req instrument.PublishRequest) []config.Repository {
+	repos := make([]config.Repository, 0)
+	for _, repoId := range req.RepoIds {
+		repo, ok := req.Repositories[repoId]
+		if !ok {
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	return repos
+}
+
+//channelDestination returns location of endpoint in channel, it is either an url or a file:// address
+func channelDestination(chn config.Channel, endpoint string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(utils.Trim(chn.Address), "/"), endpoint)
+}
+
+func planArtifactoryPackages(req instrument.PublishRequest, packages []ArtifactoryPackage) ([]instrument.PublishItem, error) {
+	items := make([]instrument.PublishItem, 0)
+	for _, repo := range selectedRepositories(req) {
+		chn := repo.GetChannel(!req.DevMode)
+		if utils.IsStringEmpty(chn.Address) {
+			return nil, fmt.Errorf("channel of repo %s is malformed", repo.Id)
+		}
+		for _, element := range packages {
+			items = append(items, instrument.PublishItem{
+				RepoId:      repo.Id,
+				Source:      element.Source,
+				Destination: channelDestination(chn, element.Endpoint),
+			})
+		}
+	}
+	return items, nil
+}
+
+//publishToChannel uploads package to endpoint relative to address of channel.
+//If channel is a file:// address then package is copied to the same relative path in that directory
+func publishToChannel(ctx context.Context, chn config.Channel, param ArtifactoryPackage) error {
+	if dir, ok := core.LocalDirectory(chn.Address); ok {
+		if utils.IsStringEmpty(dir) {
+			return fmt.Errorf("directory of channel %s is empty", chn.Address)
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(param.Endpoint))
+		log.Printf("publish package to %s", dest)
+		err := os.MkdirAll(filepath.Dir(dest), 0755)
+		if err != nil {
+			return err
+		}
+		return utils.CopyFile(param.Source, dest)
+	}
+	param.Endpoint = channelDestination(chn, param.Endpoint)
+	var err error
+	param.Username, err = config.ResolveSecret(chn.Username)
+	if err != nil {
+		return err
+	}
+	param.Password, err = config.ResolveSecret(chn.Password)
+	if err != nil {
+		return err
+	}
+	return uploadFile(ctx, param)
+}
@@ -12,79 +12,113 @@ import (
 
 const ArtifactoryMvnPublisherName = "artifactorymvn"
 
-func publishMvnJarToArtifactory(ctx context.Context, req instrument.PublishRequest) instrument.Response {
+func artifactoryMvnPackages(req instrument.PublishRequest) ([]ArtifactoryPackage, error) {
+	packDir := filepath.Join(req.OutputDir, req.ModuleName, mvnPackDir)
+	//modules that are configured before mvn packer exists have no pack stage, they are published from build output
+	if utils.IsNotExists(filepath.Join(packDir, mvnPackManifest)) {
+		return artifactoryMvnBuildOutputs(req)
+	}
+	p, err := ReadMvnPackage(packDir)
+	if err != nil {
+		return nil, fmt.Errorf("module %s must be packed by %s packer before publishing: %v",
+			req.ModuleName, MvnPackerName, err)
+	}
+
+	packages := make([]ArtifactoryPackage, 0)
+	for _, file := range p.Files {
+		source := filepath.Join(packDir, file.Name)
+		if utils.IsNotExists(source) {
+			if !req.Lenient {
+				return nil, fmt.Errorf("artifact %s of module %s not found", source, req.ModuleName)
+			}
+			continue
+		}
+		packages = append(packages, ArtifactoryPackage{
+			Source:   source,
+			Endpoint: fmt.Sprintf("%s/%s", p.ModulePath(), file.Name),
+			Md5:      file.Md5,
+		})
+	}
+	return packages, nil
+}
+
+//artifactoryMvnBuildOutputs returns jar, pom, javadoc and sources in the build output which contains pom.xml.
+//Missing jar or pom is an error unless publishing is lenient
+func artifactoryMvnBuildOutputs(req instrument.PublishRequest) ([]ArtifactoryPackage, error) {
 	targetDir := ""
 	for _, output := range req.ModuleOutputs {
-		targetDir = filepath.Join(req.OutputDir, req.ModuleName, output)
-		pomPath := filepath.Join(targetDir, "pom.xml")
-		if utils.IsNotExists(pomPath) {
-			continue
+		dir := filepath.Join(req.OutputDir, req.ModuleName, output)
+		if !utils.IsNotExists(filepath.Join(dir, "pom.xml")) {
+			targetDir = dir
+			break
 		}
 	}
+	if targetDir == "" {
+		return nil, fmt.Errorf("module %s is neither packed by %s packer nor has pom.xml in build output %v",
+			req.ModuleName, MvnPackerName, req.ModuleOutputs)
+	}
 
 	pom, err := core.ReadPOM(filepath.Join(targetDir, "pom.xml"))
 	if err != nil {
-		return instrument.ResponseError(err)
+		return nil, err
 	}
-
 	finalName := fmt.Sprintf("%s-%s", pom.ArtifactId, pom.Version)
 	if !utils.IsStringEmpty(pom.Build.FinalName) {
 		finalName = pom.Build.FinalName
 	}
+	modulePath := fmt.Sprintf("%s/%s/%s", strings.ReplaceAll(pom.GroupId, ".", "/"), pom.ArtifactId, pom.Version)
 
-	modulePath := func(p core.POM) string {
-		return fmt.Sprintf("%s/%s/%s",
-			strings.ReplaceAll(p.GroupId, ".", "/"),
-			p.ArtifactId,
-			p.Version)
-	}
-
-	temp := []ArtifactoryPackage{
-		{
-			Source:   filepath.Join(targetDir, fmt.Sprintf("%s.jar", finalName)),
-			Endpoint: fmt.Sprintf("%s/%s.jar", modulePath(pom), finalName),
-		},
-		{
-			Source:   filepath.Join(targetDir, "pom.xml"),
-			Endpoint: fmt.Sprintf("%s/%s.pom", modulePath(pom), finalName),
-		},
-		{
-			Source:   filepath.Join(targetDir, fmt.Sprintf("%s-javadoc.jar", finalName)),
-			Endpoint: fmt.Sprintf("%s/%s-javadoc.jar", modulePath(pom), finalName),
-		},
-		{
-			Source:   filepath.Join(targetDir, fmt.Sprintf("%s-sources.jar", finalName)),
-			Endpoint: fmt.Sprintf("%s/%s-sources.jar", modulePath(pom), finalName),
-		},
-	}
-
-	packages := make([]*ArtifactoryPackage, 0)
-	for _, item := range temp {
-		if utils.IsNotExists(item.Source) {
+	packages := make([]ArtifactoryPackage, 0)
+	//jar and pom are required as the packed ones are, javadoc and sources are optional
+	for _, file := range []struct {
+		source, endpoint string
+		optional         bool
+	}{
+		{fmt.Sprintf("%s.jar", finalName), fmt.Sprintf("%s.jar", finalName), false},
+		{"pom.xml", fmt.Sprintf("%s.pom", finalName), false},
+		{fmt.Sprintf("%s-javadoc.jar", finalName), fmt.Sprintf("%s-javadoc.jar", finalName), true},
+		{fmt.Sprintf("%s-sources.jar", finalName), fmt.Sprintf("%s-sources.jar", finalName), true},
+	} {
+		source := filepath.Join(targetDir, file.source)
+		if utils.IsNotExists(source) {
+			if !file.optional && !req.Lenient {
+				return nil, fmt.Errorf("artifact %s of module %s not found", source, req.ModuleName)
+			}
 			continue
 		}
-		md5, err := utils.SumContentMD5(item.Source)
+		md5, err := utils.SumContentMD5(source)
 		if err != nil {
-			return instrument.ResponseError(err)
+			return nil, err
 		}
-		p := &ArtifactoryPackage{
-			Source:   item.Source,
-			Endpoint: item.Endpoint,
+		packages = append(packages, ArtifactoryPackage{
+			Source:   source,
+			Endpoint: fmt.Sprintf("%s/%s", modulePath, file.endpoint),
 			Md5:      md5,
-		}
-		packages = append(packages, p)
+		})
+	}
+	return packages, nil
+}
+
+func planMvnJarToArtifactory(ctx context.Context, req instrument.PublishRequest) ([]instrument.PublishItem, error) {
+	packages, err := artifactoryMvnPackages(req)
+	if err != nil {
+		return nil, err
 	}
+	return planArtifactoryPackages(req, packages)
+}
 
-	for _, repo := range req.Repositories {
+func publishMvnJarToArtifactory(ctx context.Context, req instrument.PublishRequest) instrument.Response {
+	packages, err := artifactoryMvnPackages(req)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	for _, repo := range selectedRepositories(req) {
+		chn := repo.GetChannel(!req.DevMode)
+		if utils.IsStringEmpty(chn.Address) {
+			return instrument.ResponseError(fmt.Errorf("channel of repo %s is malformed", repo.Id))
+		}
 		for _, element := range packages {
-			chn := repo.GetChannel(!req.DevMode)
-			if utils.IsStringEmpty(chn.Address) {
-				return instrument.ResponseError(fmt.Errorf("channel of repo %s is malformed", repo.Id))
-			}
-			element.Endpoint = fmt.Sprintf("%s/%s", chn.Address, element.Endpoint)
-			element.Username = utils.ReadEnvVariableIfHas(chn.Username)
-			element.Password = utils.ReadEnvVariableIfHas(chn.Password)
-			err := uploadFile(ctx, *element)
+			err := publishToChannel(ctx, chn, element)
 			if err != nil {
 				return instrument.ResponseError(err)
 			}
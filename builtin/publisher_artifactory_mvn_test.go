@@ -0,0 +1,101 @@
+package builtin
+
+import (
+	"github.com/locngoxuan/buildpack/instrument"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestArtifactoryMvnBuildOutputs(t *testing.T) {
+	const pom = `<project><groupId>com.example</groupId><artifactId>app</artifactId><version>1.0.0</version></project>`
+	tests := []struct {
+		name    string
+		files   []string
+		lenient bool
+		want    []string
+		err     bool
+	}{
+		{
+			name:  "jar, pom, javadoc and sources",
+			files: []string{"pom.xml", "app-1.0.0.jar", "app-1.0.0-javadoc.jar", "app-1.0.0-sources.jar"},
+			want: []string{
+				"com/example/app/1.0.0/app-1.0.0.jar",
+				"com/example/app/1.0.0/app-1.0.0.pom",
+				"com/example/app/1.0.0/app-1.0.0-javadoc.jar",
+				"com/example/app/1.0.0/app-1.0.0-sources.jar",
+			},
+		},
+		{
+			name:  "javadoc and sources are optional",
+			files: []string{"pom.xml", "app-1.0.0.jar"},
+			want: []string{
+				"com/example/app/1.0.0/app-1.0.0.jar",
+				"com/example/app/1.0.0/app-1.0.0.pom",
+			},
+		},
+		{
+			name:  "jar is missing",
+			files: []string{"pom.xml", "app-1.0.0-sources.jar"},
+			err:   true,
+		},
+		{
+			name:    "jar is missing but publishing is lenient",
+			files:   []string{"pom.xml"},
+			lenient: true,
+			want:    []string{"com/example/app/1.0.0/app-1.0.0.pom"},
+		},
+		{
+			name:  "no pom.xml in build output",
+			files: []string{"app-1.0.0.jar"},
+			err:   true,
+		},
+	}
+	for _, tt := range tests {
+		outputDir, err := ioutil.TempDir("", "bpp-artifactory")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(outputDir)
+		targetDir := filepath.Join(outputDir, "app", "target")
+		err = os.MkdirAll(targetDir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range tt.files {
+			content := f
+			if f == "pom.xml" {
+				content = pom
+			}
+			err = ioutil.WriteFile(filepath.Join(targetDir, f), []byte(content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		req := instrument.PublishRequest{Lenient: tt.lenient}
+		req.OutputDir = outputDir
+		req.ModuleName = "app"
+		req.ModuleOutputs = []string{"target"}
+		packages, err := artifactoryMvnPackages(req)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: artifactoryMvnPackages = %+v, want error", tt.name, packages)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: artifactoryMvnPackages get error %v", tt.name, err)
+			continue
+		}
+		endpoints := make([]string, 0)
+		for _, p := range packages {
+			endpoints = append(endpoints, p.Endpoint)
+		}
+		if !reflect.DeepEqual(endpoints, tt.want) {
+			t.Errorf("%s: endpoints = %v, want %v", tt.name, endpoints, tt.want)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package builtin
 import (
 	"context"
 	"fmt"
-	"github.com/locngoxuan/buildpack/config"
 	"github.com/locngoxuan/buildpack/core"
 	"github.com/locngoxuan/buildpack/instrument"
 	"github.com/locngoxuan/buildpack/utils"
@@ -14,26 +13,15 @@ import (
 const ArtifactoryYarnPublisherName = "artifactoryyarn"
 const ArtifactoryNpmPublisherName = "artifactorynpm"
 
-func publishYarnJarToArtifactory(ctx context.Context, req instrument.PublishRequest) instrument.Response {
+func artifactoryYarnPackages(req instrument.PublishRequest) ([]ArtifactoryPackage, error) {
 	outputDist := filepath.Join(req.OutputDir, req.ModuleName, nodeOutputDir)
 	packageJsonPath := filepath.Join(req.WorkDir, req.ModulePath, "package.json")
 	packageJson, err := core.ReadPackageJson(packageJsonPath)
 	if err != nil {
-		return instrument.ResponseError(err)
+		return nil, err
 	}
 
-	c, err := config.ReadModuleConfig(filepath.Join(req.WorkDir, req.ModulePath))
-	if err != nil {
-		return instrument.ResponseError(err)
-	}
-	label := c.Label
-	if utils.Trim(label) == "" {
-		label = "SNAPSHOT"
-	}
-	ver := req.Version
-	if req.DevMode {
-		ver = fmt.Sprintf("%s-%s", req.Version, label)
-	}
+	ver := req.BuildVersion()
 
 	finalName := fmt.Sprintf("%s-%s", core.NormalizeNodePackageName(packageJson.Name), ver)
 
@@ -47,33 +35,47 @@ func publishYarnJarToArtifactory(ctx context.Context, req instrument.PublishRequ
 		},
 	}
 
-	packages := make([]*ArtifactoryPackage, 0)
+	packages := make([]ArtifactoryPackage, 0)
 	for _, item := range temp {
 		if utils.IsNotExists(item.Source) {
+			if !req.Lenient {
+				return nil, fmt.Errorf("artifact %s of module %s not found", item.Source, req.ModuleName)
+			}
 			continue
 		}
 		md5, err := utils.SumContentMD5(item.Source)
 		if err != nil {
-			return instrument.ResponseError(err)
+			return nil, err
 		}
-		p := &ArtifactoryPackage{
+		packages = append(packages, ArtifactoryPackage{
 			Source:   item.Source,
 			Endpoint: item.Endpoint,
 			Md5:      md5,
-		}
-		packages = append(packages, p)
+		})
 	}
+	return packages, nil
+}
+
+func planYarnJarToArtifactory(ctx context.Context, req instrument.PublishRequest) ([]instrument.PublishItem, error) {
+	packages, err := artifactoryYarnPackages(req)
+	if err != nil {
+		return nil, err
+	}
+	return planArtifactoryPackages(req, packages)
+}
 
-	for _, repo := range req.Repositories {
+func publishYarnJarToArtifactory(ctx context.Context, req instrument.PublishRequest) instrument.Response {
+	packages, err := artifactoryYarnPackages(req)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	for _, repo := range selectedRepositories(req) {
 		for _, element := range packages {
 			chn := repo.GetChannel(!req.DevMode)
 			if utils.IsStringEmpty(chn.Address) {
 				return instrument.ResponseError(fmt.Errorf("channel of repo %s is malformed", repo.Id))
 			}
-			element.Endpoint = fmt.Sprintf("%s/%s", chn.Address, element.Endpoint)
-			element.Username = utils.ReadEnvVariableIfHas(chn.Username)
-			element.Password = utils.ReadEnvVariableIfHas(chn.Password)
-			err := uploadFile(ctx, *element)
+			err := publishToChannel(ctx, chn, element)
 			if err != nil {
 				return instrument.ResponseError(err)
 			}
@@ -0,0 +1,178 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/instrument"
+	"github.com/locngoxuan/buildpack/utils"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+const DockerPublisherName = "docker"
+
+//dockerImageRef returns reference of image in registry, address of docker hub is empty
+func dockerImageRef(registry config.DockerRegistry, name, tag string) string {
+	address := utils.Trim(registry.Address)
+	address = strings.TrimPrefix(address, "https://")
+	address = strings.TrimPrefix(address, "http://")
+	address = strings.TrimSuffix(address, "/")
+	if address == "" {
+		return fmt.Sprintf("%s:%s", name, tag)
+	}
+	return fmt.Sprintf("%s/%s:%s", address, name, tag)
+}
+
+//dockerImageTags returns tags that are pushed to registry.
+//Release build is tagged as version and latest, while dev build is tagged as version with label only
+func dockerImageTags(ver string, devMode bool) []string {
+	if devMode {
+		return []string{ver}
+	}
+	return []string{ver, "latest"}
+}
+
+//dockerRegistries returns registries of request in order of repo ids.
+//A repository whose channel is a file:// address is also accepted as target of images, other repo ids are errors
+func dockerRegistries(req instrument.PublishRequest) ([]config.DockerRegistry, error) {
+	registries := make(map[string]config.DockerRegistry)
+	for _, registry := range req.DockerRegistries {
+		if utils.IsStringEmpty(registry.Id) {
+			continue
+		}
+		registries[registry.Id] = registry
+	}
+
+	result := make([]config.DockerRegistry, 0)
+	for _, repoId := range req.RepoIds {
+		registry, ok := registries[repoId]
+		if ok {
+			result = append(result, registry)
+			continue
+		}
+		repo, ok := req.Repositories[repoId]
+		if !ok {
+			return nil, fmt.Errorf("docker registry %s of module %s not found", repoId, req.ModuleName)
+		}
+		chn := repo.GetChannel(!req.DevMode)
+		if _, local := core.LocalDirectory(chn.Address); !local {
+			return nil, fmt.Errorf("repository %s of module %s is neither a docker registry nor a file:// address",
+				repoId, req.ModuleName)
+		}
+		result = append(result, config.DockerRegistry{
+			Id:      repo.Id,
+			Address: chn.Address,
+		})
+	}
+	return result, nil
+}
+
+//dockerImageFile returns location of OCI tarball of image in directory
+func dockerImageFile(dir, name, tag string) string {
+	return filepath.Join(dir, filepath.FromSlash(name), fmt.Sprintf("%s.tar", tag))
+}
+
+func planDockerImage(ctx context.Context, req instrument.PublishRequest) ([]instrument.PublishItem, error) {
+	c, err := ReadDockerPackConfig(filepath.Join(req.WorkDir, req.ModulePath))
+	if err != nil {
+		return nil, err
+	}
+	ver := dockerImageVersion(req.BaseProperties)
+	name := c.ImageName(req.ModuleName)
+	image := fmt.Sprintf("%s:%s", name, ver)
+	items := make([]instrument.PublishItem, 0)
+	registries, err := dockerRegistries(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, registry := range registries {
+		for _, tag := range dockerImageTags(ver, req.DevMode) {
+			dest := dockerImageRef(registry, name, tag)
+			if dir, local := core.LocalDirectory(registry.Address); local {
+				dest = dockerImageFile(dir, name, tag)
+			}
+			items = append(items, instrument.PublishItem{
+				RepoId:      registry.Id,
+				Source:      image,
+				Destination: dest,
+			})
+		}
+	}
+	return items, nil
+}
+
+//saveDockerImage writes image as OCI tarballs into {dir}/{name}/{tag}.tar, one tarball per tag
+func saveDockerImage(ctx context.Context, dockerClient core.DockerClient, dir, name, image, ver string, req instrument.PublishRequest) error {
+	if utils.IsStringEmpty(dir) {
+		return fmt.Errorf("directory of registry is empty")
+	}
+	for _, tag := range dockerImageTags(ver, req.DevMode) {
+		dest := dockerImageFile(dir, name, tag)
+		log.Printf("[%s] save image %s to %s", req.ModuleName, image, dest)
+		err := dockerClient.SaveImageAsOCI(ctx, image, tag, dest)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func publishDockerImage(ctx context.Context, req instrument.PublishRequest) instrument.Response {
+	c, err := ReadDockerPackConfig(filepath.Join(req.WorkDir, req.ModulePath))
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	ver := dockerImageVersion(req.BaseProperties)
+	name := c.ImageName(req.ModuleName)
+	image := fmt.Sprintf("%s:%s", name, ver)
+
+	dockerClient, err := core.InitDockerClient(ctx, req.DockerHosts)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	defer dockerClient.Close()
+
+	found, _, err := dockerClient.ImageExist(ctx, image)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	if !found {
+		return instrument.ResponseError(fmt.Errorf("image %s not found, module %s must be packed by %s packer before publishing",
+			image, req.ModuleName, DockerPackerName))
+	}
+
+	registries, err := dockerRegistries(req)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	for _, registry := range registries {
+		if dir, local := core.LocalDirectory(registry.Address); local {
+			err = saveDockerImage(ctx, dockerClient, dir, name, image, ver, req)
+			if err != nil {
+				return instrument.ResponseError(err)
+			}
+			continue
+		}
+		for _, tag := range dockerImageTags(ver, req.DevMode) {
+			dest := dockerImageRef(registry, name, tag)
+			log.Printf("[%s] publish image %s", req.ModuleName, dest)
+			err = dockerClient.TagImage(ctx, image, dest)
+			if err != nil {
+				return instrument.ResponseError(err)
+			}
+			r, err := dockerClient.DeployImage(ctx, registry.Username, registry.Password, dest)
+			if err != nil {
+				return instrument.ResponseError(err)
+			}
+			str, err := core.DisplayDockerLog(r)
+			_ = r.Close()
+			if err != nil {
+				return instrument.ResponseErrorWithStack(err, str)
+			}
+		}
+	}
+	return instrument.ResponseSuccess()
+}
@@ -0,0 +1,193 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/instrument"
+	"github.com/locngoxuan/buildpack/utils"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//MvnPublisherName is a publisher that follows maven deploy layout, then it works with any maven repository
+//such as Nexus, Reposilite or a local directory
+const MvnPublisherName = "mvn"
+
+const mvnSnapshotSuffix = "-SNAPSHOT"
+
+//putWithChecksums uploads data and its md5, sha1 and sha256 sidecar files
+func putWithChecksums(ctx context.Context, client core.RepositoryClient, path string, data []byte) error {
+	log.Printf("publish %s to %s", path, client.Address())
+	err := client.Put(ctx, path, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	checksums := map[string]string{
+		"md5":    fmt.Sprintf("%x", md5.Sum(data)),
+		"sha1":   fmt.Sprintf("%x", sha1.Sum(data)),
+		"sha256": fmt.Sprintf("%x", sha256.Sum256(data)),
+	}
+	for _, ext := range []string{"md5", "sha1", "sha256"} {
+		sum := []byte(checksums[ext])
+		err = client.Put(ctx, fmt.Sprintf("%s.%s", path, ext), bytes.NewReader(sum), int64(len(sum)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//readMavenMetadata returns metadata at path, or an empty one if it does not exist yet
+func readMavenMetadata(ctx context.Context, client core.RepositoryClient, path string, p MvnPackage) (core.MavenMetadata, error) {
+	data, err := client.Get(ctx, path)
+	if err != nil {
+		if errors.Is(err, core.ErrRepositoryFileNotFound) {
+			return core.MavenMetadata{
+				GroupId:    p.GroupId,
+				ArtifactId: p.ArtifactId,
+			}, nil
+		}
+		return core.MavenMetadata{}, err
+	}
+	return core.ParseMavenMetadata(data)
+}
+
+//deployMvnPackage deploys package as snapshot only if its version ends with -SNAPSHOT.
+//Dev version which is rendered from template without -SNAPSHOT is unique by itself, then it is deployed with release layout
+func deployMvnPackage(ctx context.Context, client core.RepositoryClient, packDir string, p MvnPackage) error {
+	return deployMvnPackageAt(ctx, client, packDir, p, time.Now().UTC())
+}
+
+func deployMvnPackageAt(ctx context.Context, client core.RepositoryClient, packDir string, p MvnPackage, now time.Time) error {
+	snapshot := strings.HasSuffix(p.Version, mvnSnapshotSuffix)
+	lastUpdated := now.Format(core.MavenLastUpdatedFormat)
+	artifactPath := fmt.Sprintf("%s/%s", strings.ReplaceAll(p.GroupId, ".", "/"), p.ArtifactId)
+	versionPath := p.ModulePath()
+
+	//snapshot files are deployed with unique version {base}-{timestamp}-{buildNumber}
+	fileVersion := p.Version
+	var versionMetadata core.MavenMetadata
+	if snapshot {
+		var err error
+		versionMetadata, err = readMavenMetadata(ctx, client, fmt.Sprintf("%s/%s", versionPath, core.MavenMetadataFile), p)
+		if err != nil {
+			return err
+		}
+		versionMetadata.Version = p.Version
+		versionMetadata.Versioning.Snapshot = &core.MavenSnapshot{
+			Timestamp:   now.Format(core.MavenTimestampFormat),
+			BuildNumber: versionMetadata.NextBuildNumber(),
+		}
+		versionMetadata.Versioning.LastUpdated = lastUpdated
+		fileVersion = fmt.Sprintf("%s-%s-%d", strings.TrimSuffix(p.Version, mvnSnapshotSuffix),
+			versionMetadata.Versioning.Snapshot.Timestamp, versionMetadata.Versioning.Snapshot.BuildNumber)
+	}
+
+	for _, file := range p.Files {
+		data, err := ioutil.ReadFile(filepath.Join(packDir, file.Name))
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%s-%s.%s", p.ArtifactId, fileVersion, file.Extension)
+		if file.Classifier != "" {
+			name = fmt.Sprintf("%s-%s-%s.%s", p.ArtifactId, fileVersion, file.Classifier, file.Extension)
+		}
+		err = putWithChecksums(ctx, client, fmt.Sprintf("%s/%s", versionPath, name), data)
+		if err != nil {
+			return err
+		}
+		if snapshot {
+			versionMetadata.AddSnapshotVersion(core.MavenSnapshotVersion{
+				Classifier: file.Classifier,
+				Extension:  file.Extension,
+				Value:      fileVersion,
+				Updated:    lastUpdated,
+			})
+		}
+	}
+
+	if snapshot {
+		data, err := versionMetadata.Bytes()
+		if err != nil {
+			return err
+		}
+		err = putWithChecksums(ctx, client, fmt.Sprintf("%s/%s", versionPath, core.MavenMetadataFile), data)
+		if err != nil {
+			return err
+		}
+	}
+
+	artifactMetadata, err := readMavenMetadata(ctx, client, fmt.Sprintf("%s/%s", artifactPath, core.MavenMetadataFile), p)
+	if err != nil {
+		return err
+	}
+	artifactMetadata.AddVersion(p.Version, !snapshot, lastUpdated)
+	data, err := artifactMetadata.Bytes()
+	if err != nil {
+		return err
+	}
+	return putWithChecksums(ctx, client, fmt.Sprintf("%s/%s", artifactPath, core.MavenMetadataFile), data)
+}
+
+//planMvnToRepository lists files of package, file name of snapshot is resolved when it is deployed
+func planMvnToRepository(ctx context.Context, req instrument.PublishRequest) ([]instrument.PublishItem, error) {
+	packDir := filepath.Join(req.OutputDir, req.ModuleName, mvnPackDir)
+	p, err := ReadMvnPackage(packDir)
+	if err != nil {
+		return nil, fmt.Errorf("module %s must be packed by %s packer before publishing: %v",
+			req.ModuleName, MvnPackerName, err)
+	}
+	items := make([]instrument.PublishItem, 0)
+	for _, repo := range selectedRepositories(req) {
+		chn := repo.GetChannel(!req.DevMode)
+		if utils.IsStringEmpty(chn.Address) {
+			return nil, fmt.Errorf("channel of repo %s is malformed", repo.Id)
+		}
+		for _, file := range p.Files {
+			items = append(items, instrument.PublishItem{
+				RepoId:      repo.Id,
+				Source:      filepath.Join(packDir, file.Name),
+				Destination: channelDestination(chn, fmt.Sprintf("%s/%s", p.ModulePath(), file.Name)),
+			})
+		}
+	}
+	return items, nil
+}
+
+func publishMvnToRepository(ctx context.Context, req instrument.PublishRequest) instrument.Response {
+	packDir := filepath.Join(req.OutputDir, req.ModuleName, mvnPackDir)
+	p, err := ReadMvnPackage(packDir)
+	if err != nil {
+		return instrument.ResponseError(fmt.Errorf("module %s must be packed by %s packer before publishing: %v",
+			req.ModuleName, MvnPackerName, err))
+	}
+
+	for _, repo := range selectedRepositories(req) {
+		chn := repo.GetChannel(!req.DevMode)
+		if utils.IsStringEmpty(chn.Address) {
+			return instrument.ResponseError(fmt.Errorf("channel of repo %s is malformed", repo.Id))
+		}
+		client, err := core.NewRepositoryClient(chn)
+		if err != nil {
+			return instrument.ResponseError(fmt.Errorf("channel of repo %s is malformed: %v", repo.Id, err))
+		}
+		if req.DevMode && !strings.HasSuffix(p.Version, mvnSnapshotSuffix) {
+			log.Printf("dev version %s of module %s does not end with %s, it is deployed with release layout",
+				p.Version, req.ModuleName, mvnSnapshotSuffix)
+		}
+		err = deployMvnPackage(ctx, client, packDir, p)
+		if err != nil {
+			return instrument.ResponseError(err)
+		}
+	}
+	return instrument.ResponseSuccess()
+}
@@ -0,0 +1,212 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"github.com/locngoxuan/buildpack/core"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+//memoryRepositoryClient keeps deployed files in memory
+type memoryRepositoryClient struct {
+	files map[string][]byte
+}
+
+func (c *memoryRepositoryClient) Address() string {
+	return "memory://"
+}
+
+func (c *memoryRepositoryClient) Get(ctx context.Context, path string) ([]byte, error) {
+	data, ok := c.files[path]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", core.ErrRepositoryFileNotFound, path)
+	}
+	return data, nil
+}
+
+func (c *memoryRepositoryClient) Put(ctx context.Context, path string, data io.Reader, size int64) error {
+	bytes, err := ioutil.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	c.files[path] = bytes
+	return nil
+}
+
+func (c *memoryRepositoryClient) metadata(t *testing.T, path string) core.MavenMetadata {
+	t.Helper()
+	data, ok := c.files[path]
+	if !ok {
+		t.Fatalf("%s is not deployed", path)
+	}
+	m, err := core.ParseMavenMetadata(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+//testMvnPackage writes files of package into a temporary pack directory
+func testMvnPackage(t *testing.T, version string) (string, MvnPackage) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "bpp-mvn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	p := MvnPackage{
+		GroupId:    "com.example",
+		ArtifactId: "app",
+		Version:    version,
+		Packaging:  "jar",
+		Files: []MvnPackageFile{
+			{Name: "app.jar", Extension: "jar"},
+			{Name: "app.pom", Extension: "pom"},
+			{Name: "app-sources.jar", Classifier: "sources", Extension: "jar"},
+		},
+	}
+	for _, f := range p.Files {
+		err = ioutil.WriteFile(filepath.Join(dir, f.Name), []byte(f.Name), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, p
+}
+
+func deployedFiles(c *memoryRepositoryClient, dir string) []string {
+	files := make([]string, 0)
+	for path := range c.files {
+		if filepath.Dir(path) == dir && filepath.Ext(path) != ".md5" &&
+			filepath.Ext(path) != ".sha1" && filepath.Ext(path) != ".sha256" {
+			files = append(files, filepath.Base(path))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestDeployMvnPackage(t *testing.T) {
+	first := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	tests := []struct {
+		name     string
+		versions []string
+		dir      string
+		files    []string
+		//build number of snapshot metadata, 0 if version is not a snapshot
+		buildNumber int
+		versionList []string
+		latest      string
+		release     string
+	}{
+		{
+			name:        "release",
+			versions:    []string{"1.0.0"},
+			dir:         "com/example/app/1.0.0",
+			files:       []string{"app-1.0.0-sources.jar", "app-1.0.0.jar", "app-1.0.0.pom"},
+			versionList: []string{"1.0.0"},
+			latest:      "1.0.0",
+			release:     "1.0.0",
+		},
+		{
+			name:     "snapshot deployed twice",
+			versions: []string{"1.1.0-SNAPSHOT", "1.1.0-SNAPSHOT"},
+			dir:      "com/example/app/1.1.0-SNAPSHOT",
+			files: []string{
+				"app-1.1.0-20261018.120000-1-sources.jar", "app-1.1.0-20261018.120000-1.jar", "app-1.1.0-20261018.120000-1.pom",
+				"app-1.1.0-20261018.130000-2-sources.jar", "app-1.1.0-20261018.130000-2.jar", "app-1.1.0-20261018.130000-2.pom",
+				core.MavenMetadataFile,
+			},
+			buildNumber: 2,
+			versionList: []string{"1.1.0-SNAPSHOT"},
+			latest:      "1.1.0-SNAPSHOT",
+		},
+		{
+			name:        "dev version without snapshot suffix",
+			versions:    []string{"1.4.0-dev.57+abc"},
+			dir:         "com/example/app/1.4.0-dev.57+abc",
+			files:       []string{"app-1.4.0-dev.57+abc-sources.jar", "app-1.4.0-dev.57+abc.jar", "app-1.4.0-dev.57+abc.pom"},
+			versionList: []string{"1.4.0-dev.57+abc"},
+			latest:      "1.4.0-dev.57+abc",
+			release:     "1.4.0-dev.57+abc",
+		},
+		{
+			name:        "release after snapshot",
+			versions:    []string{"1.0.0-SNAPSHOT", "1.0.0"},
+			dir:         "com/example/app/1.0.0",
+			files:       []string{"app-1.0.0-sources.jar", "app-1.0.0.jar", "app-1.0.0.pom"},
+			versionList: []string{"1.0.0-SNAPSHOT", "1.0.0"},
+			latest:      "1.0.0",
+			release:     "1.0.0",
+		},
+	}
+	for _, tt := range tests {
+		client := &memoryRepositoryClient{files: make(map[string][]byte)}
+		for i, version := range tt.versions {
+			dir, p := testMvnPackage(t, version)
+			now := first
+			if i > 0 {
+				now = second
+			}
+			err := deployMvnPackageAt(context.Background(), client, dir, p, now)
+			if err != nil {
+				t.Fatalf("%s: deploy %s get error %v", tt.name, version, err)
+			}
+		}
+
+		if got := deployedFiles(client, tt.dir); !reflect.DeepEqual(got, tt.files) {
+			t.Errorf("%s: files = %v, want %v", tt.name, got, tt.files)
+		}
+		if data := client.files[tt.dir+"/app-"+tt.latest+".jar"]; tt.buildNumber == 0 && string(data) != "app.jar" {
+			t.Errorf("%s: content of jar = %q, want app.jar", tt.name, data)
+		}
+		for _, ext := range []string{"md5", "sha1", "sha256"} {
+			if _, ok := client.files[fmt.Sprintf("com/example/app/%s.%s", core.MavenMetadataFile, ext)]; !ok {
+				t.Errorf("%s: %s checksum of artifact metadata is not deployed", tt.name, ext)
+			}
+		}
+
+		artifact := client.metadata(t, "com/example/app/"+core.MavenMetadataFile)
+		if artifact.GroupId != "com.example" || artifact.ArtifactId != "app" {
+			t.Errorf("%s: artifact metadata is of %s:%s", tt.name, artifact.GroupId, artifact.ArtifactId)
+		}
+		if artifact.Versioning.Versions == nil || !reflect.DeepEqual(artifact.Versioning.Versions.Version, tt.versionList) {
+			t.Errorf("%s: versions = %+v, want %v", tt.name, artifact.Versioning.Versions, tt.versionList)
+		}
+		if artifact.Versioning.Latest != tt.latest || artifact.Versioning.Release != tt.release {
+			t.Errorf("%s: latest, release = %s, %s, want %s, %s", tt.name,
+				artifact.Versioning.Latest, artifact.Versioning.Release, tt.latest, tt.release)
+		}
+
+		if tt.buildNumber == 0 {
+			continue
+		}
+		snapshot := client.metadata(t, tt.dir+"/"+core.MavenMetadataFile)
+		if snapshot.Version != tt.versions[0] {
+			t.Errorf("%s: version of snapshot metadata = %s, want %s", tt.name, snapshot.Version, tt.versions[0])
+		}
+		if s := snapshot.Versioning.Snapshot; s == nil || s.BuildNumber != tt.buildNumber || s.Timestamp != "20261018.130000" {
+			t.Errorf("%s: snapshot = %+v, want build number %d at 20261018.130000", tt.name, s, tt.buildNumber)
+		}
+		//older entries of same classifier and extension are replaced by the latest deployment
+		want := []core.MavenSnapshotVersion{
+			{Extension: "jar", Value: "1.1.0-20261018.130000-2", Updated: "20261018130000"},
+			{Extension: "pom", Value: "1.1.0-20261018.130000-2", Updated: "20261018130000"},
+			{Classifier: "sources", Extension: "jar", Value: "1.1.0-20261018.130000-2", Updated: "20261018130000"},
+		}
+		if snapshot.Versioning.SnapshotVersions == nil ||
+			!reflect.DeepEqual(snapshot.Versioning.SnapshotVersions.SnapshotVersion, want) {
+			t.Errorf("%s: snapshot versions = %+v, want %+v", tt.name, snapshot.Versioning.SnapshotVersions, want)
+		}
+	}
+}
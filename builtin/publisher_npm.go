@@ -0,0 +1,291 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/instrument"
+	"github.com/locngoxuan/buildpack/utils"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+//NpmPublisherName is a publisher that follows npm registry protocol,
+//then package can be installed by npm install from any npm registry such as npmjs, Verdaccio or Nexus
+const NpmPublisherName = "npm"
+
+const (
+	npmReleaseTag = "latest"
+	npmDevTag     = "next"
+
+	npmPackageDocument = "package.json"
+)
+
+type NpmPackage struct {
+	Name     string
+	Version  string
+	Tag      string
+	Tarball  string
+	Manifest map[string]interface{}
+}
+
+//npmEscapeName escapes slash of scoped package, e.g. @scope/name becomes @scope%2fname
+func npmEscapeName(name string) string {
+	return strings.Replace(name, "/", "%2f", 1)
+}
+
+//npmTarballName returns file name of tarball in registry, scope is not part of it
+func npmTarballName(name, version string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return fmt.Sprintf("%s-%s.tgz", name, version)
+}
+
+func readNpmPackage(req instrument.PublishRequest) (p NpmPackage, err error) {
+	moduleDir := filepath.Join(req.WorkDir, req.ModulePath)
+	c, err := config.ReadModuleConfig(moduleDir)
+	if err != nil {
+		return
+	}
+	p.Version = req.BuildVersion()
+	p.Tag = npmReleaseTag
+	if req.DevMode {
+		p.Tag = npmDevTag
+		if !utils.IsStringEmpty(c.Label) {
+			p.Tag = strings.ToLower(utils.Trim(c.Label))
+		}
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(moduleDir, "package.json"))
+	if err != nil {
+		err = fmt.Errorf("read package.json get error %v", err)
+		return
+	}
+	err = json.Unmarshal(data, &p.Manifest)
+	if err != nil {
+		err = fmt.Errorf("unmarshal package.json get error %v", err)
+		return
+	}
+	name, _ := p.Manifest["name"].(string)
+	if utils.IsStringEmpty(name) {
+		err = fmt.Errorf("package.json is malformed: missing name property")
+		return
+	}
+	p.Name = utils.Trim(name)
+	p.Manifest["version"] = p.Version
+	p.Manifest["_id"] = fmt.Sprintf("%s@%s", p.Name, p.Version)
+
+	//npm and yarn packers name tarball by normalized name of package
+	fileName := fmt.Sprintf("%s-%s.tgz", core.NormalizeNodePackageName(p.Name), p.Version)
+	for _, dir := range []string{nodeOutputDir, "dist"} {
+		tarball := filepath.Join(req.OutputDir, req.ModuleName, dir, fileName)
+		if !utils.IsNotExists(tarball) {
+			p.Tarball = tarball
+			return
+		}
+	}
+	err = fmt.Errorf("tarball %s not found, module %s must be packed before publishing", fileName, req.ModuleName)
+	return
+}
+
+//npmVersionManifest returns manifest of a version in registry, dist describes where and how to verify tarball
+func npmVersionManifest(p NpmPackage, data []byte, registry string) map[string]interface{} {
+	integrity := sha512.Sum512(data)
+	manifest := make(map[string]interface{})
+	for k, v := range p.Manifest {
+		manifest[k] = v
+	}
+	manifest["dist"] = map[string]interface{}{
+		"shasum":    fmt.Sprintf("%x", sha1.Sum(data)),
+		"integrity": fmt.Sprintf("sha512-%s", base64.StdEncoding.EncodeToString(integrity[:])),
+		"tarball":   fmt.Sprintf("%s/%s/-/%s", registry, p.Name, npmTarballName(p.Name, p.Version)),
+	}
+	return manifest
+}
+
+//npmPublishDocument creates document of PUT /{name} request which is sent by npm publish
+func npmPublishDocument(p NpmPackage, registry string) ([]byte, error) {
+	data, err := ioutil.ReadFile(p.Tarball)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{
+		"_id":  p.Name,
+		"name": p.Name,
+		"dist-tags": map[string]string{
+			p.Tag: p.Version,
+		},
+		"versions": map[string]interface{}{
+			p.Version: npmVersionManifest(p, data, registry),
+		},
+		"_attachments": map[string]interface{}{
+			npmTarballName(p.Name, p.Version): map[string]interface{}{
+				"content_type": "application/octet-stream",
+				"data":         base64.StdEncoding.EncodeToString(data),
+				"length":       len(data),
+			},
+		},
+	}
+	if description, ok := p.Manifest["description"]; ok {
+		doc["description"] = description
+	}
+	return json.Marshal(doc)
+}
+
+//npmPublishToDirectory writes tarball and package document into directory with same layout as registry serves them,
+//document of package is merged with the existing one so that previous versions are kept
+func npmPublishToDirectory(ctx context.Context, p NpmPackage, chn config.Channel) error {
+	client, err := core.NewRepositoryClient(chn)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(p.Tarball)
+	if err != nil {
+		return err
+	}
+	tarballPath := fmt.Sprintf("%s/-/%s", p.Name, npmTarballName(p.Name, p.Version))
+	log.Printf("publish package %s@%s to %s with tag %s", p.Name, p.Version, client.Address(), p.Tag)
+	err = client.Put(ctx, tarballPath, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	docPath := fmt.Sprintf("%s/%s", p.Name, npmPackageDocument)
+	doc := make(map[string]interface{})
+	existing, err := client.Get(ctx, docPath)
+	if err != nil && !errors.Is(err, core.ErrRepositoryFileNotFound) {
+		return err
+	}
+	if err == nil {
+		err = json.Unmarshal(existing, &doc)
+		if err != nil {
+			return fmt.Errorf("unmarshal %s get error %v", docPath, err)
+		}
+	}
+	versions, _ := doc["versions"].(map[string]interface{})
+	if versions == nil {
+		versions = make(map[string]interface{})
+	}
+	versions[p.Version] = npmVersionManifest(p, data, strings.TrimSuffix(client.Address(), "/"))
+	tags, _ := doc["dist-tags"].(map[string]interface{})
+	if tags == nil {
+		tags = make(map[string]interface{})
+	}
+	tags[p.Tag] = p.Version
+	doc["_id"] = p.Name
+	doc["name"] = p.Name
+	doc["versions"] = versions
+	doc["dist-tags"] = tags
+	if description, ok := p.Manifest["description"]; ok {
+		doc["description"] = description
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return client.Put(ctx, docPath, bytes.NewReader(out), int64(len(out)))
+}
+
+func npmPublish(ctx context.Context, p NpmPackage, chn config.Channel) error {
+	registry := strings.TrimSuffix(utils.Trim(chn.Address), "/")
+	doc, err := npmPublishDocument(p, registry)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/%s", registry, npmEscapeName(p.Name))
+	log.Printf("publish package %s@%s to %s with tag %s", p.Name, p.Version, registry, p.Tag)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(doc))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	token, err := config.ResolveSecret(chn.Token)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	} else if !utils.IsStringEmpty(chn.Username) {
+		username, err := config.ResolveSecret(chn.Username)
+		if err != nil {
+			return err
+		}
+		password, err := config.ResolveSecret(chn.Password)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(username, password)
+	}
+
+	client := &http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("publish %s@%s get error %s %s", p.Name, p.Version, res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func planNpmToRegistry(ctx context.Context, req instrument.PublishRequest) ([]instrument.PublishItem, error) {
+	p, err := readNpmPackage(req)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]instrument.PublishItem, 0)
+	for _, repo := range selectedRepositories(req) {
+		chn := repo.GetChannel(!req.DevMode)
+		if utils.IsStringEmpty(chn.Address) {
+			return nil, fmt.Errorf("channel of repo %s is malformed", repo.Id)
+		}
+		endpoint := npmEscapeName(p.Name)
+		if _, ok := core.LocalDirectory(chn.Address); ok {
+			endpoint = fmt.Sprintf("%s/-/%s", p.Name, npmTarballName(p.Name, p.Version))
+		}
+		items = append(items, instrument.PublishItem{
+			RepoId:      repo.Id,
+			Source:      p.Tarball,
+			Destination: fmt.Sprintf("%s (tag %s)", channelDestination(chn, endpoint), p.Tag),
+		})
+	}
+	return items, nil
+}
+
+func publishNpmToRegistry(ctx context.Context, req instrument.PublishRequest) instrument.Response {
+	p, err := readNpmPackage(req)
+	if err != nil {
+		return instrument.ResponseError(err)
+	}
+	for _, repo := range selectedRepositories(req) {
+		chn := repo.GetChannel(!req.DevMode)
+		if utils.IsStringEmpty(chn.Address) {
+			return instrument.ResponseError(fmt.Errorf("channel of repo %s is malformed", repo.Id))
+		}
+		if _, ok := core.LocalDirectory(chn.Address); ok {
+			err = npmPublishToDirectory(ctx, p, chn)
+		} else {
+			err = npmPublish(ctx, p, chn)
+		}
+		if err != nil {
+			return instrument.ResponseError(err)
+		}
+	}
+	return instrument.ResponseSuccess()
+}
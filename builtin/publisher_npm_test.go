@@ -0,0 +1,220 @@
+package builtin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/instrument"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+//npmRegistryRequest is publish request which is received by stub registry
+type npmRegistryRequest struct {
+	Path          string
+	Authorization string
+	Document      struct {
+		Id          string                 `json:"_id"`
+		Name        string                 `json:"name"`
+		DistTags    map[string]string      `json:"dist-tags"`
+		Versions    map[string]interface{} `json:"versions"`
+		Attachments map[string]struct {
+			ContentType string `json:"content_type"`
+			Data        string `json:"data"`
+			Length      int    `json:"length"`
+		} `json:"_attachments"`
+	}
+}
+
+//npmRegistryStub accepts publish requests as Verdaccio does
+func npmRegistryStub(t *testing.T, received *[]npmRegistryRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req npmRegistryRequest
+		req.Path = r.URL.EscapedPath()
+		req.Authorization = r.Header.Get("Authorization")
+		err := json.NewDecoder(r.Body).Decode(&req.Document)
+		if err != nil {
+			t.Errorf("decode publish document get error %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*received = append(*received, req)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"ok":"created new package"}`))
+	}))
+}
+
+//testNpmModule writes package.json, Module.bpp and packed tarball of module
+func testNpmModule(t *testing.T, name, label, version string) instrument.BaseProperties {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "bpp-npm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	moduleDir := filepath.Join(dir, "web")
+	outputDir := filepath.Join(dir, ".bpp")
+	tarballDir := filepath.Join(outputDir, "web", nodeOutputDir)
+	for _, d := range []string{moduleDir, tarballDir} {
+		if err = os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	moduleConfig := "build:\n  type: npm\n"
+	if label != "" {
+		moduleConfig = moduleConfig + "  label: " + label + "\n"
+	}
+	files := map[string]string{
+		filepath.Join(moduleDir, config.ConfigModule):          moduleConfig,
+		filepath.Join(moduleDir, "package.json"):               `{"name": "` + name + `", "version": "0.0.0", "description": "web"}`,
+		filepath.Join(tarballDir, "scope-web-"+version+".tgz"): "tarball of " + version,
+	}
+	for file, content := range files {
+		if err = ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return instrument.BaseProperties{
+		WorkDir:    dir,
+		OutputDir:  outputDir,
+		ModuleName: "web",
+		ModulePath: "web",
+	}
+}
+
+func TestPublishNpmToRegistry(t *testing.T) {
+	tests := []struct {
+		name          string
+		devMode       bool
+		label         string
+		channel       config.Channel
+		version       string
+		tags          map[string]string
+		authorization string
+	}{
+		{
+			name:          "release with token",
+			channel:       config.Channel{Token: "t0ken", Username: "ignored", Password: "ignored"},
+			version:       "1.2.0",
+			tags:          map[string]string{"latest": "1.2.0"},
+			authorization: "Bearer t0ken",
+		},
+		{
+			name:          "dev build with basic auth",
+			devMode:       true,
+			channel:       config.Channel{Username: "deployer", Password: "s3cr3t"},
+			version:       "1.2.0-SNAPSHOT",
+			tags:          map[string]string{"next": "1.2.0-SNAPSHOT"},
+			authorization: "Basic " + base64.StdEncoding.EncodeToString([]byte("deployer:s3cr3t")),
+		},
+		{
+			name:    "dev build with label",
+			devMode: true,
+			label:   "Beta",
+			version: "1.2.0-SNAPSHOT",
+			tags:    map[string]string{"beta": "1.2.0-SNAPSHOT"},
+		},
+	}
+	for _, tt := range tests {
+		received := make([]npmRegistryRequest, 0)
+		server := npmRegistryStub(t, &received)
+
+		base := testNpmModule(t, "@scope/web", tt.label, tt.version)
+		base.Version = "1.2.0"
+		base.DevMode = tt.devMode
+		chn := tt.channel
+		chn.Address = server.URL + "/"
+		req := instrument.PublishRequest{
+			BaseProperties: base,
+			PublishConfig:  config.PublishConfig{Type: NpmPublisherName, RepoIds: []string{"verdaccio"}},
+			Repositories: map[string]config.Repository{
+				"verdaccio": {Id: "verdaccio", RelChannel: chn, DevChannel: chn},
+			},
+		}
+		res := publishNpmToRegistry(context.Background(), req)
+		server.Close()
+		if !res.Success {
+			t.Errorf("%s: publish get error %v", tt.name, res.Err)
+			continue
+		}
+		if len(received) != 1 {
+			t.Errorf("%s: registry receives %d requests, want 1", tt.name, len(received))
+			continue
+		}
+		r := received[0]
+		if r.Path != "/@scope%2fweb" {
+			t.Errorf("%s: path = %s, want /@scope%%2fweb", tt.name, r.Path)
+		}
+		if r.Authorization != tt.authorization {
+			t.Errorf("%s: authorization = %q, want %q", tt.name, r.Authorization, tt.authorization)
+		}
+		doc := r.Document
+		if doc.Id != "@scope/web" || doc.Name != "@scope/web" {
+			t.Errorf("%s: document is of %s (%s), want @scope/web", tt.name, doc.Name, doc.Id)
+		}
+		if !reflect.DeepEqual(doc.DistTags, tt.tags) {
+			t.Errorf("%s: dist-tags = %v, want %v", tt.name, doc.DistTags, tt.tags)
+		}
+		manifest, ok := doc.Versions[tt.version].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: version %s is not in document %v", tt.name, tt.version, doc.Versions)
+			continue
+		}
+		dist, _ := manifest["dist"].(map[string]interface{})
+		wantTarball := server.URL + "/@scope/web/-/web-" + tt.version + ".tgz"
+		if manifest["version"] != tt.version || dist["tarball"] != wantTarball {
+			t.Errorf("%s: manifest is of version %v at %v, want %s at %s", tt.name,
+				manifest["version"], dist["tarball"], tt.version, wantTarball)
+		}
+		attachment, ok := doc.Attachments["web-"+tt.version+".tgz"]
+		if !ok {
+			t.Errorf("%s: attachment is not found in %v", tt.name, doc.Attachments)
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(attachment.Data)
+		if err != nil {
+			t.Errorf("%s: decode attachment get error %v", tt.name, err)
+			continue
+		}
+		if string(data) != "tarball of "+tt.version || attachment.Length != len(data) {
+			t.Errorf("%s: attachment = %q (length %d), want tarball of %s", tt.name, data, attachment.Length, tt.version)
+		}
+	}
+}
+
+func TestNpmPublishRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"cannot publish over existing version"}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+	base := testNpmModule(t, "@scope/web", "", "1.2.0")
+	base.Version = "1.2.0"
+	chn := config.Channel{Address: server.URL, Token: "t0ken"}
+	req := instrument.PublishRequest{
+		BaseProperties: base,
+		PublishConfig:  config.PublishConfig{Type: NpmPublisherName, RepoIds: []string{"verdaccio"}},
+		Repositories: map[string]config.Repository{
+			"verdaccio": {Id: "verdaccio", RelChannel: chn},
+		},
+	}
+	res := publishNpmToRegistry(context.Background(), req)
+	if res.Success {
+		t.Fatal("publish over existing version is successful, want error")
+	}
+	want := `publish @scope/web@1.2.0 get error 403 Forbidden {"error":"cannot publish over existing version"}`
+	if res.Err == nil || res.Err.Error() != want {
+		t.Errorf("error = %v, want %s", res.Err, want)
+	}
+}
@@ -0,0 +1,198 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/locngoxuan/buildpack/instrument"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	PomVersionUpdaterName         = "pom"
+	PackageJsonVersionUpdaterName = "package_json"
+	ChartVersionUpdaterName       = "chart"
+	RegexVersionUpdaterName       = "regex"
+
+	pomFile         = "pom.xml"
+	packageJsonFile = "package.json"
+	chartFile       = "Chart.yaml"
+)
+
+//replaceRange returns content in which [start, end) is replaced by value
+func replaceRange(content []byte, start, end int64, value string) []byte {
+	var buf bytes.Buffer
+	buf.Write(content[:start])
+	buf.WriteString(value)
+	buf.Write(content[end:])
+	return buf.Bytes()
+}
+
+//xmlElement is location of an element in xml content
+type xmlElement struct {
+	//Start and End is range of element text
+	Start, End int64
+	//Tag is raw name of self-closing element, e.g. revision or m:revision
+	Tag string
+	//SelfClosing is true if element is written as <revision/>, then Start and End is range of the whole element
+	SelfClosing bool
+}
+
+//xmlElementText returns location of text of element at path, e.g. project/properties/revision
+func xmlElementText(content []byte, path ...string) (xmlElement, bool, error) {
+	d := xml.NewDecoder(bytes.NewReader(content))
+	stack := make([]string, 0)
+	var elementStart, start int64 = -1, -1
+	for {
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			return xmlElement{}, false, nil
+		}
+		if err != nil {
+			return xmlElement{}, false, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if strings.Join(stack, "/") == strings.Join(path, "/") {
+				elementStart = offset
+				start = d.InputOffset()
+			}
+		case xml.EndElement:
+			if start >= 0 && strings.Join(stack, "/") == strings.Join(path, "/") {
+				//end element of <revision/> is given by decoder without reading any byte
+				if offset == start && bytes.HasSuffix(content[:start], []byte("/>")) {
+					tag := strings.TrimPrefix(string(content[elementStart:start]), "<")
+					if i := strings.IndexAny(tag, " \t\r\n/"); i >= 0 {
+						tag = tag[:i]
+					}
+					return xmlElement{Start: elementStart, End: start, Tag: tag, SelfClosing: true}, true, nil
+				}
+				return xmlElement{Start: start, End: offset}, true, nil
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+//updatePomVersion replaces property revision if pom uses CI friendly versions, otherwise version of project.
+//Empty element such as <revision/> is rewritten as <revision>version</revision>
+func updatePomVersion(request instrument.VersionUpdateRequest) ([]byte, error) {
+	for _, path := range [][]string{{"project", "properties", "revision"}, {"project", "version"}} {
+		e, ok, err := xmlElementText(request.Content, path...)
+		if err != nil {
+			return nil, fmt.Errorf("parse pom get error %v", err)
+		}
+		if !ok {
+			continue
+		}
+		if e.SelfClosing {
+			return replaceRange(request.Content, e.Start, e.End, fmt.Sprintf("<%s>%s</%s>", e.Tag, request.Version, e.Tag)), nil
+		}
+		text := string(request.Content[e.Start:e.End])
+		if path[len(path)-1] == "version" && strings.Contains(text, "${") {
+			return nil, fmt.Errorf("version of project is %s but its property is not found", strings.TrimSpace(text))
+		}
+		return replaceRange(request.Content, e.Start, e.End, request.Version), nil
+	}
+	return nil, fmt.Errorf("neither property revision nor version of project is found")
+}
+
+//updatePackageJsonVersion replaces top-level version, formatting of file is kept as it is
+func updatePackageJsonVersion(request instrument.VersionUpdateRequest) ([]byte, error) {
+	type frame struct {
+		object bool
+		key    bool
+	}
+	d := json.NewDecoder(bytes.NewReader(request.Content))
+	stack := make([]*frame, 0)
+	versionNext := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse package.json get error %v", err)
+		}
+		if t, ok := tok.(json.Delim); ok && (t == '}' || t == ']') {
+			stack = stack[:len(stack)-1]
+		} else if top := len(stack) - 1; top >= 0 && stack[top].object && stack[top].key {
+			stack[top].key = false
+			versionNext = top == 0 && tok == "version"
+			continue
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '{' || t == '[' {
+				stack = append(stack, &frame{object: t == '{', key: t == '{'})
+				continue
+			}
+		case string:
+			if versionNext {
+				end := d.InputOffset()
+				start := int64(bytes.LastIndexByte(request.Content[:end-1], '"'))
+				value, err := json.Marshal(request.Version)
+				if err != nil {
+					return nil, err
+				}
+				return replaceRange(request.Content, start, end, string(value)), nil
+			}
+		}
+		versionNext = false
+		if len(stack) > 0 && stack[len(stack)-1].object {
+			stack[len(stack)-1].key = true
+		}
+	}
+	return nil, fmt.Errorf("version is not found in package.json")
+}
+
+var chartVersionPattern = regexp.MustCompile(`(?m)^((?:version|appVersion):[ \t]*)(["']?)[^"'\r\n#]*?(["']?)([ \t]*(?:#.*)?)$`)
+
+//updateChartVersion replaces version and appVersion of Helm chart
+func updateChartVersion(request instrument.VersionUpdateRequest) ([]byte, error) {
+	if !chartVersionPattern.Match(request.Content) {
+		return nil, fmt.Errorf("version is not found in chart")
+	}
+	replacement := fmt.Sprintf("${1}${2}%s${3}${4}", strings.ReplaceAll(request.Version, "$", "$$"))
+	return chartVersionPattern.ReplaceAll(request.Content, []byte(replacement)), nil
+}
+
+//updateRegexVersion replaces group of pattern which captures version in every match
+func updateRegexVersion(request instrument.VersionUpdateRequest) ([]byte, error) {
+	if strings.TrimSpace(request.Pattern) == "" {
+		return nil, fmt.Errorf("pattern of version file %s is empty", request.File)
+	}
+	pattern, err := regexp.Compile(request.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("compile pattern get error %v", err)
+	}
+	group := pattern.SubexpIndex("version")
+	if group < 0 {
+		group = 1
+	}
+	if pattern.NumSubexp() < group {
+		return nil, fmt.Errorf("pattern %s does not have a group which captures version", request.Pattern)
+	}
+	matches := pattern.FindAllSubmatchIndex(request.Content, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("pattern %s does not match content of %s", request.Pattern, request.File)
+	}
+	var buf bytes.Buffer
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		buf.Write(request.Content[last:start])
+		buf.WriteString(request.Version)
+		last = end
+	}
+	buf.Write(request.Content[last:])
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+package builtin
+
+import (
+	"github.com/locngoxuan/buildpack/instrument"
+	"testing"
+)
+
+func TestUpdatePomVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		err     bool
+	}{
+		{
+			name:    "version of project",
+			content: "<project>\n  <version>1.0.0</version>\n</project>\n",
+			want:    "<project>\n  <version>1.1.0</version>\n</project>\n",
+		},
+		{
+			name: "version of parent is kept",
+			content: "<project>\n  <parent><version>2.0.0</version></parent>\n" +
+				"  <version>1.0.0</version>\n</project>",
+			want: "<project>\n  <parent><version>2.0.0</version></parent>\n" +
+				"  <version>1.1.0</version>\n</project>",
+		},
+		{
+			name: "property revision",
+			content: "<project>\n  <version>${revision}</version>\n" +
+				"  <properties>\n    <revision>1.0.0</revision>\n  </properties>\n</project>",
+			want: "<project>\n  <version>${revision}</version>\n" +
+				"  <properties>\n    <revision>1.1.0</revision>\n  </properties>\n</project>",
+		},
+		{
+			name:    "empty property revision",
+			content: "<project><properties><revision></revision></properties></project>",
+			want:    "<project><properties><revision>1.1.0</revision></properties></project>",
+		},
+		{
+			name:    "self-closing property revision",
+			content: "<project>\n  <properties>\n    <revision/>\n    <java.version>11</java.version>\n  </properties>\n</project>",
+			want:    "<project>\n  <properties>\n    <revision>1.1.0</revision>\n    <java.version>11</java.version>\n  </properties>\n</project>",
+		},
+		{
+			name:    "self-closing property revision with space",
+			content: "<project><properties><revision /></properties><version>${revision}</version></project>",
+			want:    "<project><properties><revision>1.1.0</revision></properties><version>${revision}</version></project>",
+		},
+		{
+			name:    "self-closing version of project",
+			content: "<project><version/></project>",
+			want:    "<project><version>1.1.0</version></project>",
+		},
+		{
+			name:    "property of version not found",
+			content: "<project><version>${revision}</version></project>",
+			err:     true,
+		},
+		{
+			name:    "no version",
+			content: "<project><artifactId>app</artifactId></project>",
+			err:     true,
+		},
+		{
+			name:    "malformed pom",
+			content: "<project><version>1.0.0</project>",
+			err:     true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := updatePomVersion(instrument.VersionUpdateRequest{
+			Content: []byte(tt.content),
+			Version: "1.1.0",
+		})
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: updatePomVersion = %q, want error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: updatePomVersion get error %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: updatePomVersion = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
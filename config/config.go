@@ -25,25 +25,44 @@ type ProjectConfig struct {
 	Modules      []ModuleInfo `yaml:"modules,omitempty"`
 	GitConfig    `yaml:"git,omitempty"`
 	DockerConfig `yaml:"docker,omitempty"`
-	RepoConfig   []Repository `yaml:"repositories,omitempty"`
+	RepoConfig   []Repository    `yaml:"repositories,omitempty"`
+	Changelog    ChangelogConfig `yaml:"changelog,omitempty"`
+	//DevVersion is template of version of dev build, e.g. {{.Version}}-dev.{{.BuildNumber}}+{{.GitShortSha}}.
+	//Default is {{.Version}}-{{.Label}} where label of module is SNAPSHOT if it is not configured
+	DevVersion string `yaml:"dev_version,omitempty"`
+	//Profiles overlay project config, see Profiles
+	Profiles Profiles `yaml:"profiles,omitempty"`
+	//Secrets is location of encrypted secrets file which credentials can refer to, see SecretsConfig
+	Secrets SecretsConfig `yaml:"secrets,omitempty"`
 }
 
 type ModuleInfo struct {
-	Id   int    `yaml:"id,omitempty"`
-	Name string `yaml:"name,omitempty"`
-	Path string `yaml:"path,omitempty"`
+	Id        int      `yaml:"id,omitempty"`
+	Name      string   `yaml:"name,omitempty"`
+	Path      string   `yaml:"path,omitempty"`
+	Version   string   `yaml:"version,omitempty"`
+	DependsOn []string `yaml:"depends_on,omitempty"`
 }
 
 type ModuleConfig struct {
+	//Version of module, if it is empty then module follows version of project
+	Version     string `yaml:"version,omitempty" json:"version,omitempty"`
 	BuildConfig `yaml:"build,omitempty" json:"build,omitempty"`
 	PackConfig  `yaml:"pack,omitempty" json:"pack,omitempty"`
 	Publish     []PublishConfig `yaml:"publish,omitempty" json:"publish,omitempty"`
+	//VersionFiles are updated to next version of module by pump
+	VersionFiles []VersionFile `yaml:"version_files,omitempty" json:"version_files,omitempty"`
+	//Profiles overlay module config, they are chosen by name of profile of project
+	Profiles Profiles `yaml:"profiles,omitempty" json:"profiles,omitempty"`
 }
 
 type BuildOutputInfo struct {
 	Version     string `yaml:"build_mode,omitempty" json:"version,omitempty"`
 	Release     bool   `yaml:"release,omitempty" json:"release,omitempty"`
-	BuildNumber int    `yaml:"build_number,omitempty" json:"build_number, omitempty" `
+	BuildNumber int    `yaml:"build_number,omitempty" json:"build_number,omitempty"`
+	//GitSha and Timestamp are taken when build starts, then pack and publish render the same dev version
+	GitSha    string `yaml:"git_sha,omitempty" json:"git_sha,omitempty"`
+	Timestamp string `yaml:"timestamp,omitempty" json:"timestamp,omitempty"`
 }
 
 func ReadProjectConfig(workDir, argConfigFile string) (c ProjectConfig, err error) {
@@ -63,7 +82,11 @@ func ReadProjectConfig(workDir, argConfigFile string) (c ProjectConfig, err erro
 		err = fmt.Errorf("read application config file get error %v", err)
 		return
 	}
-	err = yaml.Unmarshal(yamlFile, &c)
+	return ParseProjectConfig(yamlFile)
+}
+
+func ParseProjectConfig(data []byte) (c ProjectConfig, err error) {
+	err = yaml.Unmarshal(data, &c)
 	if err != nil {
 		err = fmt.Errorf("unmarshal application config file get error %v", err)
 		return
@@ -119,19 +142,12 @@ func WriteBuildOutputInfo(out BuildOutputInfo, dir string) error {
 }
 
 func ReadModuleConfig(moduleDir string) (c ModuleConfig, err error) {
-	configFile := filepath.Join(moduleDir, ConfigModule)
-	_, err = os.Stat(configFile)
-	if os.IsNotExist(err) {
-		err = fmt.Errorf("build config file %s not found", configFile)
-		return
-	}
-
-	yamlFile, err := ioutil.ReadFile(configFile)
+	//module config is overlaid by active profile
+	r, err := NewModuleResolver(moduleDir)
 	if err != nil {
-		err = fmt.Errorf("read build config file get error %v", err)
 		return
 	}
-	err = yaml.Unmarshal(yamlFile, &c)
+	err = r.Decode(&c)
 	if err != nil {
 		err = fmt.Errorf("unmarshal build config file get error %v", err)
 		return
@@ -0,0 +1,24 @@
+package config
+
+const DefaultChangelogFile = "CHANGELOG.md"
+
+/**
+Example:
+
+changelog:
+  file: CHANGELOG.md
+  template: .changelog.tmpl
+*/
+type ChangelogConfig struct {
+	//File is location of changelog which is relative to working directory
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+	//Template is location of text/template file that renders section of a release
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+}
+
+func (c ChangelogConfig) ChangelogFile() string {
+	if c.File == "" {
+		return DefaultChangelogFile
+	}
+	return c.File
+}
@@ -18,6 +18,7 @@ type DockerConfig struct {
 }
 
 type DockerRegistry struct {
+	Id       string `json:"id,omitempty" yaml:"id,omitempty"`
 	Address  string `json:"address,omitempty" yaml:"address,omitempty"`
 	Username string `json:"username,omitempty" yaml:"username,omitempty"`
 	Password string `json:"password,omitempty" yaml:"password,omitempty"`
@@ -1,19 +1,87 @@
 package config
 
+/**
+Example:
+
+git:
+  branch: master
+  remote: https://github.com/locngoxuan/buildpack.git
+  author:
+    name: release-bot
+    email: $RELEASE_BOT_EMAIL
+  tag_name: v{{.Version}}
+  tag_message: release {{.Version}}
+  commit_message: "chore(release): prepare for {{.Version}}"
+  signing:
+    format: gpg
+    key: /secrets/release-bot.asc
+    passphrase: $GPG_PASSPHRASE
+
+Templates are rendered with .Version, .PreviousVersion and .Module (empty if whole project is pumped).
+Version of tag is the released version, version of commit is the next version.
+Tag name must end with version, then tags of previous versions are recognized
+*/
 type GitConfig struct {
 	Branch        string `yaml:"branch,omitempty" json:"branch,omitempty"`
 	RemoteAddress string `yaml:"remote,omitempty" json:"remote,omitempty"`
 	GitCredential `yaml:"credential,omitempty" json:"credential,omitempty"`
+	Author        GitAuthor  `yaml:"author,omitempty" json:"author,omitempty"`
+	TagName       string     `yaml:"tag_name,omitempty" json:"tag_name,omitempty"`
+	TagMessage    string     `yaml:"tag_message,omitempty" json:"tag_message,omitempty"`
+	CommitMessage string     `yaml:"commit_message,omitempty" json:"commit_message,omitempty"`
+	Signing       GitSigning `yaml:"signing,omitempty" json:"signing,omitempty"`
+}
+
+//GitAuthor is author and committer of commits and tagger of tags that are created by bpp
+type GitAuthor struct {
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	Email string `yaml:"email,omitempty" json:"email,omitempty"`
+}
+
+const (
+	SigningGpg = "gpg"
+	SigningSsh = "ssh"
+)
+
+//GitSigning is key for signing commits and tags, nothing is signed if format is empty.
+//Key of gpg is an armored private key file, key of ssh is a private key file or a public key file whose private key is in ssh-agent
+type GitSigning struct {
+	Format     string `yaml:"format,omitempty" json:"format,omitempty"`
+	Key        string `yaml:"key,omitempty" json:"key,omitempty"`
+	Passphrase string `yaml:"passphrase,omitempty" json:"passphrase,omitempty"`
 }
 
 const (
 	CredentialToken   = "token"
 	CredentialAccount = "account"
+	CredentialSsh     = "ssh"
+	CredentialHelper  = "helper"
 )
 
+/**
+Example:
+
+git:
+  remote: git@github.com:locngoxuan/buildpack.git
+  credential:
+    type: ssh
+    private_key: ~/.ssh/id_rsa
+    passphrase: $SSH_KEY_PASSPHRASE
+
+Type of credential is one of
+  - token: access_token is used for HTTP basic auth
+  - account: username and password are used for HTTP basic auth
+  - ssh: private_key or ssh-agent if private_key is empty, host key is verified against known_hosts
+  - helper: credential of remote is read from ~/.git-credentials, or asked to GIT_ASKPASS program
+*/
 type GitCredential struct {
 	Type        string `yaml:"type,omitempty" json:"type,omitempty"`
 	AccessToken string `yaml:"access_token,omitempty" json:"access_token,omitempty"`
 	Username    string `yaml:"username,omitempty" json:"username,omitempty"`
 	Password    string `yaml:"password,omitempty" json:"password,omitempty"`
+	//PrivateKey is path of ssh private key, ssh-agent is used if it is empty
+	PrivateKey string `yaml:"private_key,omitempty" json:"private_key,omitempty"`
+	Passphrase string `yaml:"passphrase,omitempty" json:"passphrase,omitempty"`
+	//KnownHosts is list of known_hosts files, default is SSH_KNOWN_HOSTS or ~/.ssh/known_hosts
+	KnownHosts string `yaml:"known_hosts,omitempty" json:"known_hosts,omitempty"`
 }
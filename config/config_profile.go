@@ -0,0 +1,508 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	//EnvProfile chooses profile if it is not given by --profile
+	EnvProfile = "BPP_PROFILE"
+	//EnvOverridePrefix is prefix of environment variables that override project config.
+	//Keys are separated by double underscores, e.g. BPP_GIT__BRANCH overrides git.branch
+	EnvOverridePrefix = "BPP_"
+
+	SourceGlobal = "global"
+	SourceEnv    = "env"
+	SourceFlag   = "flag"
+)
+
+/**
+Example:
+
+profiles:
+  ci:
+    git:
+      branch: develop
+    repositories:
+      - id: nexus
+        channel_dev:
+          address: https://nexus.ci.local/repository/snapshots
+    modules:
+      - name: web
+        version: 2.0.0
+  local: {}
+
+Profile is chosen by --profile or BPP_PROFILE and it must be declared in project config, its values overlay project config.
+Module config can declare profiles of the same names to overlay module config, e.g.
+
+profiles:
+  local:
+    build:
+      image: node:lts-alpine
+
+Lists of modules, repositories and docker registries are merged item by item by name or id, docker hosts of all layers are kept,
+other lists are replaced
+*/
+type Profiles map[string]interface{}
+
+var activeProfile string
+
+//SetProfile chooses profile which overlays project config and module config, empty name means no profile
+func SetProfile(name string) {
+	activeProfile = strings.TrimSpace(name)
+}
+
+func ActiveProfile() string {
+	return activeProfile
+}
+
+//Origin is a resolved value and the layer where it comes from
+type Origin struct {
+	Path   string      `json:"path"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+//mergeKeys are keys of items of lists that are merged item by item
+var mergeKeys = map[string]string{
+	"modules":           "name",
+	"repositories":      "id",
+	"docker.registries": "id",
+}
+
+//unionLists are lists whose items of all layers are kept
+var unionLists = map[string]struct{}{
+	"docker.hosts": {},
+}
+
+//Resolver merges layers of config, a layer that is added later takes precedence over the ones added before
+type Resolver struct {
+	values  map[string]interface{}
+	origins map[string]Origin
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		values:  make(map[string]interface{}),
+		origins: make(map[string]Origin),
+	}
+}
+
+//Add merges values of a layer, source tells where values come from, e.g. Project.bpp or env BPP_GIT__BRANCH
+func (r *Resolver) Add(source string, values map[string]interface{}) {
+	r.merge(r.values, values, "", "", source)
+}
+
+func (r *Resolver) Values() map[string]interface{} {
+	return r.values
+}
+
+//Origins returns resolved values that are not maps in order of their paths
+func (r *Resolver) Origins() []Origin {
+	origins := make([]Origin, 0, len(r.origins))
+	for _, o := range r.origins {
+		origins = append(origins, o)
+	}
+	sort.Slice(origins, func(i, j int) bool {
+		return origins[i].Path < origins[j].Path
+	})
+	return origins
+}
+
+//Decode puts resolved values into out
+func (r *Resolver) Decode(out interface{}) error {
+	data, err := yaml.Marshal(r.values)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return fmt.Sprintf("%s.%s", path, key)
+}
+
+//itemPath returns path of list item, it is named by merge key if item has one, e.g. repositories[nexus]
+func itemPath(path string, item interface{}, itemKey string, index int) string {
+	if m, ok := item.(map[string]interface{}); ok && itemKey != "" {
+		if key, ok := m[itemKey]; ok && key != nil {
+			return fmt.Sprintf("%s[%v]", path, key)
+		}
+	}
+	return fmt.Sprintf("%s[%d]", path, index)
+}
+
+//merge puts src into dst, schemaPath is path without list items which is used for choosing how a list is merged
+func (r *Resolver) merge(dst, src map[string]interface{}, path, schemaPath, source string) {
+	for key, value := range src {
+		p := joinPath(path, key)
+		sp := joinPath(schemaPath, key)
+		current, exists := dst[key]
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if m, ok := current.(map[string]interface{}); ok {
+				r.merge(m, v, p, sp, source)
+				continue
+			}
+		case []interface{}:
+			if l, ok := current.([]interface{}); ok && exists {
+				if itemKey, ok := mergeKeys[sp]; ok {
+					dst[key] = r.mergeItems(l, v, p, sp, itemKey, source)
+					continue
+				}
+				if _, ok := unionLists[sp]; ok {
+					dst[key] = r.union(l, v, p, source)
+					continue
+				}
+			}
+		}
+		r.forget(p)
+		dst[key] = value
+		r.record(value, p, sp, source)
+	}
+}
+
+func (r *Resolver) mergeItems(dst, src []interface{}, path, schemaPath, itemKey, source string) []interface{} {
+	for _, item := range src {
+		m, ok := item.(map[string]interface{})
+		found := false
+		for i := 0; ok && i < len(dst); i++ {
+			d, isMap := dst[i].(map[string]interface{})
+			if isMap && m[itemKey] != nil && fmt.Sprint(d[itemKey]) == fmt.Sprint(m[itemKey]) {
+				//key of item stays with the layer which declares item
+				values := make(map[string]interface{}, len(m))
+				for k, v := range m {
+					if k != itemKey {
+						values[k] = v
+					}
+				}
+				r.merge(d, values, itemPath(path, d, itemKey, i), schemaPath, source)
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, item)
+			r.record(item, itemPath(path, item, itemKey, len(dst)-1), schemaPath, source)
+		}
+	}
+	return dst
+}
+
+func (r *Resolver) union(dst, src []interface{}, path, source string) []interface{} {
+	added := false
+	for _, item := range src {
+		found := false
+		for _, d := range dst {
+			if fmt.Sprint(d) == fmt.Sprint(item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, item)
+			added = true
+		}
+	}
+	o := r.origins[path]
+	if added && o.Source != source {
+		o.Source = fmt.Sprintf("%s, %s", o.Source, source)
+	}
+	o.Path = path
+	o.Value = dst
+	r.origins[path] = o
+	return dst
+}
+
+//record sets source of values at path, lists of merge keys are recorded item by item
+func (r *Resolver) record(value interface{}, path, schemaPath, source string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			for key, item := range v {
+				r.record(item, joinPath(path, key), joinPath(schemaPath, key), source)
+			}
+			return
+		}
+	case []interface{}:
+		if itemKey, ok := mergeKeys[schemaPath]; ok && len(v) > 0 {
+			for i, item := range v {
+				r.record(item, itemPath(path, item, itemKey, i), schemaPath, source)
+			}
+			return
+		}
+	}
+	r.origins[path] = Origin{
+		Path:   path,
+		Value:  value,
+		Source: source,
+	}
+}
+
+//forget removes sources of path and values under it since they are replaced
+func (r *Resolver) forget(path string) {
+	for p := range r.origins {
+		if p == path || strings.HasPrefix(p, path+".") || strings.HasPrefix(p, path+"[") {
+			delete(r.origins, p)
+		}
+	}
+}
+
+//nodeValue converts yaml node into maps, lists and scalars. Floats are kept as text, then version 1.0 is not turned into 1
+func nodeValue(n *yaml.Node) (interface{}, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return nodeValue(n.Content[0])
+	case yaml.AliasNode:
+		return nodeValue(n.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]interface{})
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v, err := nodeValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[n.Content[i].Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		l := make([]interface{}, 0, len(n.Content))
+		for _, item := range n.Content {
+			v, err := nodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		}
+		return l, nil
+	}
+	switch n.ShortTag() {
+	case "!!int", "!!bool", "!!null":
+		var v interface{}
+		err := n.Decode(&v)
+		return v, err
+	}
+	return n.Value, nil
+}
+
+//parseLayer returns values of yaml document, it must be a mapping
+func parseLayer(data []byte) (map[string]interface{}, error) {
+	var n yaml.Node
+	err := yaml.Unmarshal(data, &n)
+	if err != nil {
+		return nil, err
+	}
+	v, err := nodeValue(&n)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return make(map[string]interface{}), nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("content is not a mapping")
+	}
+	return m, nil
+}
+
+func readLayer(file string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseLayer(data)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal %s get error %v", file, err)
+	}
+	return values, nil
+}
+
+//takeProfile removes profiles from values and returns values of active profile
+func takeProfile(values map[string]interface{}) (map[string]interface{}, bool) {
+	profiles, _ := values["profiles"].(map[string]interface{})
+	delete(values, "profiles")
+	if activeProfile == "" || profiles == nil {
+		return nil, false
+	}
+	p, ok := profiles[activeProfile]
+	if !ok {
+		return nil, false
+	}
+	m, _ := p.(map[string]interface{})
+	if m == nil {
+		m = make(map[string]interface{})
+	}
+	return m, true
+}
+
+//NewProjectResolver returns resolver of project config whose layers are global config, project config and active profile.
+//Environment variables and flags are added by caller since they take precedence over them
+func NewProjectResolver(workDir, argConfigFile string) (*Resolver, error) {
+	r := NewResolver()
+	userHome, err := os.UserHomeDir()
+	if err == nil {
+		globalFile := filepath.Join(userHome, OutputDir, ConfigGlobal)
+		if _, err := os.Stat(globalFile); err == nil {
+			values, err := readLayer(globalFile)
+			if err != nil {
+				return nil, err
+			}
+			r.Add(fmt.Sprintf("%s %s", SourceGlobal, filepath.Join("~", OutputDir, ConfigGlobal)), values)
+		}
+	}
+
+	configFile := argConfigFile
+	if configFile == "" {
+		configFile = filepath.Join(workDir, ConfigProject)
+	}
+	_, err = os.Stat(configFile)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("configuration file not found")
+	}
+	values, err := readLayer(configFile)
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := takeProfile(values)
+	r.Add(filepath.Base(configFile), values)
+	if activeProfile == "" {
+		return r, nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("profile %s is not declared in %s", activeProfile, filepath.Base(configFile))
+	}
+	r.Add(fmt.Sprintf("profile %s", activeProfile), profile)
+	return r, nil
+}
+
+//AddEnv adds a layer for each environment variable which overrides project config, e.g. BPP_GIT__BRANCH=develop.
+//Value is plain text, only a value in brackets or braces is read as yaml list or map, e.g. [a, b] and {key: value}.
+//Variables whose keys are not in project schema are not config, they are skipped with a warning
+func (r *Resolver) AddEnv(environ []string) {
+	sort.Strings(environ)
+	for _, env := range environ {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], EnvOverridePrefix) || parts[0] == EnvProfile {
+			continue
+		}
+		keys := strings.Split(strings.ToLower(strings.TrimPrefix(parts[0], EnvOverridePrefix)), "__")
+		node, ok := projectSchemaNode(keys)
+		if !ok {
+			log.Printf("WARN: environment variable %s is skipped since %s is not a key of project config",
+				parts[0], strings.Join(keys, "."))
+			continue
+		}
+		var value interface{} = parts[1]
+		text := strings.TrimSpace(parts[1])
+		if (strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]")) ||
+			(strings.HasPrefix(text, "{") && strings.HasSuffix(text, "}")) {
+			layer, err := parseLayer([]byte(fmt.Sprintf("value: %s", text)))
+			if err != nil {
+				log.Printf("WARN: environment variable %s is skipped since its value is malformed: %v", parts[0], err)
+				continue
+			}
+			value = layer["value"]
+		}
+		if _, isMap := value.(map[string]interface{}); !isMap && schemaIsObject(node) {
+			log.Printf("WARN: environment variable %s is skipped since %s must be a map, e.g. {key: value}",
+				parts[0], strings.Join(keys, "."))
+			continue
+		}
+		r.Add(fmt.Sprintf("%s %s", SourceEnv, parts[0]), NestedValue(value, keys...))
+	}
+}
+
+var (
+	projectSchemaOnce sync.Once
+	projectSchema     map[string]interface{}
+)
+
+//projectSchemaNode returns schema of value at path of keys in project schema, keys of maps whose keys are free are accepted
+func projectSchemaNode(keys []string) (map[string]interface{}, bool) {
+	projectSchemaOnce.Do(func() {
+		_ = json.Unmarshal(ProjectSchema, &projectSchema)
+	})
+	node := projectSchema
+	for _, key := range keys {
+		properties, _ := node["properties"].(map[string]interface{})
+		if child, ok := properties[key].(map[string]interface{}); ok {
+			node = child
+			continue
+		}
+		child, ok := node["additionalProperties"].(map[string]interface{})
+		if !ok || key == "" {
+			return nil, false
+		}
+		node = child
+	}
+	return node, true
+}
+
+//schemaIsObject returns true if schema accepts nothing but maps and null
+func schemaIsObject(node map[string]interface{}) bool {
+	switch t := node["type"].(type) {
+	case string:
+		return t == "object"
+	case []interface{}:
+		for _, item := range t {
+			if item != "object" && item != "null" {
+				return false
+			}
+		}
+		return len(t) > 0
+	}
+	return false
+}
+
+//NestedValue returns layer which has value at path of keys
+func NestedValue(value interface{}, keys ...string) map[string]interface{} {
+	for i := len(keys) - 1; i > 0; i-- {
+		value = map[string]interface{}{keys[i]: value}
+	}
+	return map[string]interface{}{keys[0]: value}
+}
+
+//NewModuleResolver returns resolver of module config whose layers are module config and active profile
+func NewModuleResolver(moduleDir string) (*Resolver, error) {
+	configFile := filepath.Join(moduleDir, ConfigModule)
+	_, err := os.Stat(configFile)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("build config file %s not found", configFile)
+	}
+	values, err := readLayer(configFile)
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := takeProfile(values)
+	r := NewResolver()
+	r.Add(ConfigModule, values)
+	if ok {
+		r.Add(fmt.Sprintf("profile %s", activeProfile), profile)
+	}
+	return r, nil
+}
+
+//ReadModuleDocument returns values of module config which are overlaid by active profile
+func ReadModuleDocument(moduleDir string) (map[string]interface{}, error) {
+	r, err := NewModuleResolver(moduleDir)
+	if err != nil {
+		return nil, err
+	}
+	return r.Values(), nil
+}
@@ -36,6 +36,7 @@ type Channel struct {
 	Address  string `yaml:"address,omitempty" json:"address,omitempty"`
 	Username string `yaml:"username,omitempty" json:"username,omitempty"`
 	Password string `yaml:"password,omitempty" json:"password,omitempty"`
+	Token    string `yaml:"token,omitempty" json:"token,omitempty"`
 }
 
 func ReadGlobalRepositoryConfig() (c GlobalRepositoryConfig, err error) {
@@ -0,0 +1,13 @@
+package config
+
+import (
+	_ "embed"
+)
+
+//ProjectSchema is JSON Schema of project config, it can also be used by editors for completion of Project.bpp
+//go:embed schema/project.json
+var ProjectSchema []byte
+
+//ModuleSchema is JSON Schema of module config. Keys of build and pack depend on their type, then they are checked by builder and packer
+//go:embed schema/module.json
+var ModuleSchema []byte
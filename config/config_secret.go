@@ -0,0 +1,240 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/locngoxuan/buildpack/utils"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	SecretEnvPrefix  = "env:"
+	SecretFilePrefix = "file:"
+	SecretCmdPrefix  = "cmd:"
+	//SecretPrefix refers to a value of secrets file by its path, e.g. secret:nexus.password
+	SecretPrefix = "secret:"
+
+	SecretsFile     = "secrets.age"
+	SecretsIdentity = "age.key"
+	ageProgram      = "age"
+)
+
+/**
+Example:
+
+secrets:
+  file: ~/.bpp/secrets.age
+  identity: ~/.bpp/age.key
+
+repositories:
+  - id: nexus
+    channel_rel:
+      username: env:NEXUS_USER
+      password: secret:nexus.password
+docker:
+  registries:
+    - id: hub
+      password: cmd:pass show docker/hub
+git:
+  credential:
+    type: token
+    access_token: file:/run/secrets/git_token
+
+Secrets file is an age-encrypted yaml which is decrypted by age once per run, e.g.
+
+nexus:
+  password: s3cr3t
+
+It is created by: age -R ~/.bpp/age.pub -o ~/.bpp/secrets.age secrets.yaml.
+If identity does not exist, age asks passphrase of file that is encrypted by age -p
+*/
+type SecretsConfig struct {
+	File     string `yaml:"file,omitempty" json:"file,omitempty"`
+	Identity string `yaml:"identity,omitempty" json:"identity,omitempty"`
+}
+
+var (
+	secretsConfig SecretsConfig
+	secretsOnce   sync.Once
+	secrets       map[string]interface{}
+	secretsErr    error
+	secretsMutex  sync.Mutex
+	cmdSecrets    = make(map[string]string)
+)
+
+//SetSecretsConfig sets location of secrets file and identity, it must be called before any secret is resolved
+func SetSecretsConfig(c SecretsConfig) {
+	secretsConfig = c
+}
+
+//IsSecretReference returns true if value is not plain text but refers to a secret or an environment variable
+func IsSecretReference(value string) bool {
+	value = utils.Trim(value)
+	for _, prefix := range []string{"$", SecretEnvPrefix, SecretFilePrefix, SecretCmdPrefix, SecretPrefix} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+//ResolveSecret returns value of credential which may refer to a secret:
+//  $NAME                  environment variable, value is kept as it is if variable is empty
+//  env:NAME               environment variable which must be set
+//  file:/run/secrets/x    content of file without trailing new line
+//  cmd:pass show x        output of command without trailing new line, command is run once per run
+//  secret:nexus.password  value in encrypted secrets file
+//other values are plain text
+func ResolveSecret(value string) (string, error) {
+	value = utils.Trim(value)
+	switch {
+	case strings.HasPrefix(value, "$"):
+		return utils.ReadEnvVariableIfHas(value), nil
+	case strings.HasPrefix(value, SecretEnvPrefix):
+		name := utils.Trim(strings.TrimPrefix(value, SecretEnvPrefix))
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(value, SecretFilePrefix):
+		file, err := expandHome(utils.Trim(strings.TrimPrefix(value, SecretFilePrefix)))
+		if err != nil {
+			return "", err
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("read secret file get error %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(value, SecretCmdPrefix):
+		return commandSecret(utils.Trim(strings.TrimPrefix(value, SecretCmdPrefix)))
+	case strings.HasPrefix(value, SecretPrefix):
+		return fileSecret(utils.Trim(strings.TrimPrefix(value, SecretPrefix)))
+	}
+	return value, nil
+}
+
+func commandSecret(command string) (string, error) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	if v, ok := cmdSecrets[command]; ok {
+		return v, nil
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("run secret command get error %v", commandError(err, stderr))
+	}
+	v := strings.TrimRight(string(out), "\r\n")
+	cmdSecrets[command] = v
+	return v, nil
+}
+
+//fileSecret returns value at path of secrets file, keys of path are separated by dots
+func fileSecret(path string) (string, error) {
+	secretsOnce.Do(func() {
+		secrets, secretsErr = readSecretsFile()
+	})
+	if secretsErr != nil {
+		return "", secretsErr
+	}
+	var value interface{} = secrets
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("secret %s not found", path)
+		}
+		value, ok = m[key]
+		if !ok {
+			return "", fmt.Errorf("secret %s not found", path)
+		}
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return "", fmt.Errorf("secret %s is not a value", path)
+	}
+	return fmt.Sprint(value), nil
+}
+
+//readSecretsFile decrypts secrets file by age, identity is given only if it exists
+func readSecretsFile() (map[string]interface{}, error) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	file := secretsConfig.File
+	if utils.IsStringEmpty(file) {
+		file = filepath.Join(userHome, OutputDir, SecretsFile)
+	}
+	file, err = expandHome(utils.ReadEnvVariableIfHas(file))
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsNotExists(file) {
+		return nil, fmt.Errorf("secrets file %s not found", file)
+	}
+	identity := secretsConfig.Identity
+	if utils.IsStringEmpty(identity) {
+		identity = filepath.Join(userHome, OutputDir, SecretsIdentity)
+	}
+	identity, err = expandHome(utils.ReadEnvVariableIfHas(identity))
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"--decrypt"}
+	if !utils.IsNotExists(identity) {
+		args = append(args, "--identity", identity)
+	} else if !utils.IsStringEmpty(secretsConfig.Identity) {
+		return nil, fmt.Errorf("identity %s of secrets file not found", identity)
+	}
+	args = append(args, file)
+	var stderr bytes.Buffer
+	cmd := exec.Command(ageProgram, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("decrypt secrets file %s get error %v", file, commandError(err, stderr))
+	}
+	values, err := parseLayer(out)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal secrets file get error %v", err)
+	}
+	return values, nil
+}
+
+//commandError appends output of command to its error
+func commandError(err error, stderr bytes.Buffer) string {
+	if utils.IsStringEmpty(stderr.String()) {
+		return err.Error()
+	}
+	return fmt.Sprintf("%v: %s", err, utils.Trim(stderr.String()))
+}
+
+func expandHome(p string) (string, error) {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p, nil
+	}
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userHome, strings.TrimPrefix(p, "~")), nil
+}
+
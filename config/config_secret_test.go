@@ -0,0 +1,188 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//setEnv sets environment variable until test is completed
+func setEnv(t *testing.T, name, value string) {
+	t.Helper()
+	old, ok := os.LookupEnv(name)
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(name, old)
+			return
+		}
+		_ = os.Unsetenv(name)
+	})
+	_ = os.Setenv(name, value)
+}
+
+//resetSecrets forgets secrets of previous test, then secrets file is decrypted and commands are run again
+func resetSecrets(t *testing.T, c SecretsConfig) {
+	t.Helper()
+	reset := func(c SecretsConfig) {
+		secretsConfig = c
+		secretsOnce = sync.Once{}
+		secrets = nil
+		secretsErr = nil
+		cmdSecrets = make(map[string]string)
+	}
+	reset(c)
+	t.Cleanup(func() {
+		reset(SecretsConfig{})
+	})
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "bpp-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func writeFile(t *testing.T, file, content string, perm os.FileMode) {
+	t.Helper()
+	err := ioutil.WriteFile(file, []byte(content), perm)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret commands of test are shell scripts")
+	}
+	dir := tempDir(t)
+	//age is replaced by a script which prints secrets file as it is, identity is not required
+	writeFile(t, filepath.Join(dir, ageProgram), "#!/bin/sh\nfor f; do :; done\ncat \"$f\"\n", 0755)
+	setEnv(t, "PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	secretsFile := filepath.Join(dir, SecretsFile)
+	writeFile(t, secretsFile, "nexus:\n  password: s3cr3t\n  port: 8081\ndocker: {}\n", 0600)
+	writeFile(t, filepath.Join(dir, "token"), "t0ken\n", 0600)
+	setEnv(t, "BPP_TEST_USER", "deployer")
+	setEnv(t, "BPP_TEST_EMPTY", "")
+	_ = os.Unsetenv("BPP_TEST_UNSET")
+
+	tests := []struct {
+		value string
+		want  string
+		err   string
+	}{
+		{value: "plain", want: "plain"},
+		{value: "  plain  ", want: "plain"},
+		{value: "", want: ""},
+		{value: "$BPP_TEST_USER", want: "deployer"},
+		//variable which is empty or not set is kept as it is
+		{value: "$BPP_TEST_UNSET", want: "$BPP_TEST_UNSET"},
+		{value: "env:BPP_TEST_USER", want: "deployer"},
+		{value: "env: BPP_TEST_USER", want: "deployer"},
+		{value: "env:BPP_TEST_EMPTY", want: ""},
+		{value: "env:BPP_TEST_UNSET", err: "environment variable BPP_TEST_UNSET is not set"},
+		{value: "file:" + filepath.Join(dir, "token"), want: "t0ken"},
+		{value: "file:" + filepath.Join(dir, "missing"), err: "read secret file get error"},
+		{value: "cmd:printf 'p4ss\\n'", want: "p4ss"},
+		{value: "cmd:echo failed >&2; exit 3", err: "run secret command get error exit status 3: failed"},
+		{value: "secret:nexus.password", want: "s3cr3t"},
+		{value: "secret:nexus.port", want: "8081"},
+		{value: "secret:nexus.username", err: "secret nexus.username not found"},
+		{value: "secret:nexus.password.x", err: "secret nexus.password.x not found"},
+		{value: "secret:nexus", err: "secret nexus is not a value"},
+		{value: "secret:docker", err: "secret docker is not a value"},
+	}
+	resetSecrets(t, SecretsConfig{File: secretsFile})
+	for _, tt := range tests {
+		got, err := ResolveSecret(tt.value)
+		if tt.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("ResolveSecret(%q) error = %v, want %s", tt.value, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ResolveSecret(%q) get error %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveSecret(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSecretRunsCommandOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret commands of test are shell scripts")
+	}
+	resetSecrets(t, SecretsConfig{})
+	counter := filepath.Join(tempDir(t), "counter")
+	command := "cmd:echo x >> " + counter + "; wc -l < " + counter
+	for i := 0; i < 3; i++ {
+		got, err := ResolveSecret(command)
+		if err != nil {
+			t.Fatalf("ResolveSecret get error %v", err)
+		}
+		if strings.TrimSpace(got) != "1" {
+			t.Errorf("command is run %s times, want once", strings.TrimSpace(got))
+		}
+	}
+}
+
+func TestResolveSecretDecryptsFileOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret commands of test are shell scripts")
+	}
+	dir := tempDir(t)
+	counter := filepath.Join(dir, "counter")
+	writeFile(t, filepath.Join(dir, ageProgram), "#!/bin/sh\necho x >> "+counter+"\nfor f; do :; done\ncat \"$f\"\n", 0755)
+	setEnv(t, "PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	secretsFile := filepath.Join(dir, SecretsFile)
+	writeFile(t, secretsFile, "a: 1\nb: 2\n", 0600)
+	resetSecrets(t, SecretsConfig{File: secretsFile})
+	for _, value := range []string{"secret:a", "secret:b", "secret:c", "secret:a"} {
+		_, _ = ResolveSecret(value)
+	}
+	data, err := ioutil.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "x"); n != 1 {
+		t.Errorf("secrets file is decrypted %d times, want once", n)
+	}
+}
+
+func TestResolveSecretMissingFiles(t *testing.T) {
+	dir := tempDir(t)
+	secretsFile := filepath.Join(dir, SecretsFile)
+	writeFile(t, secretsFile, "a: 1\n", 0600)
+	tests := []struct {
+		config SecretsConfig
+		err    string
+	}{
+		{
+			config: SecretsConfig{File: filepath.Join(dir, "missing.age")},
+			err:    "secrets file " + filepath.Join(dir, "missing.age") + " not found",
+		},
+		{
+			config: SecretsConfig{File: secretsFile, Identity: filepath.Join(dir, "missing.key")},
+			err:    "identity " + filepath.Join(dir, "missing.key") + " of secrets file not found",
+		},
+	}
+	for _, tt := range tests {
+		resetSecrets(t, tt.config)
+		_, err := ResolveSecret("secret:a")
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("error = %v, want %s", err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+/**
+Example:
+
+version_files:
+  - type: pom
+  - type: package_json
+  - type: chart
+    file: charts/app/Chart.yaml
+  - type: regex
+    file: src/version.go
+    pattern: 'const Version = "([^"]+)"'
+
+Files are relative to module, default file of pom is pom.xml, of package_json is package.json and of chart is Chart.yaml.
+Pattern of regex must have a group which captures version, it is the group named version if there is one, otherwise the first group
+*/
+type VersionFile struct {
+	Type    string `yaml:"type,omitempty" json:"type,omitempty"`
+	File    string `yaml:"file,omitempty" json:"file,omitempty"`
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+}
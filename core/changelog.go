@@ -0,0 +1,237 @@
+package core
+
+import (
+	"bytes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"path"
+	"strings"
+	"time"
+)
+
+//ChangelogModule is a module of project that commits are grouped by, path is relative to root of repository
+type ChangelogModule struct {
+	Name string
+	Path string
+}
+
+//Changelog is data of a release section which is rendered by changelog template
+type Changelog struct {
+	Version         string
+	PreviousVersion string
+	Date            string
+	Groups          []ChangelogGroup
+}
+
+type ChangelogGroup struct {
+	Type    string
+	Title   string
+	Modules []ChangelogModuleEntries
+}
+
+type ChangelogModuleEntries struct {
+	Name    string
+	Path    string
+	Entries []ChangelogEntry
+}
+
+type ChangelogEntry struct {
+	Hash        string
+	ShortHash   string
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+}
+
+const changelogOtherType = "other"
+
+//changelogGroups defines order and title of groups, commits of unknown types are put into others
+var changelogGroups = []struct {
+	Type  string
+	Title string
+}{
+	{"breaking", "Breaking Changes"},
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"refactor", "Code Refactoring"},
+	{"docs", "Documentation"},
+	{changelogOtherType, "Others"},
+}
+
+//changedFiles returns files that are changed by commit in comparison with its first parent
+func changedFiles(commit *object.Commit) ([]string, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return nil, err
+		}
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, change := range changes {
+		if change.To.Name != "" {
+			files = append(files, change.To.Name)
+		}
+		if change.From.Name != "" && change.From.Name != change.To.Name {
+			files = append(files, change.From.Name)
+		}
+	}
+	return files, nil
+}
+
+//moduleOfFile returns module whose path is the longest prefix of file, module at root of project matches any file
+func moduleOfFile(modules []ChangelogModule, file string) (ChangelogModule, bool) {
+	var found ChangelogModule
+	length := -1
+	for _, m := range modules {
+		p := strings.Trim(path.Clean(strings.ReplaceAll(m.Path, "\\", "/")), "/")
+		if p == "." || p == "" {
+			if length < 0 {
+				found, length = m, 0
+			}
+			continue
+		}
+		if (file == p || strings.HasPrefix(file, p+"/")) && len(p) > length {
+			found, length = m, len(p)
+		}
+	}
+	return found, length >= 0
+}
+
+//NewChangelog groups commits by type of conventional commit, then by modules whose files are changed by commit.
+//Merge commits are ignored, a commit which does not follow conventional commits is grouped into others
+func NewChangelog(version, previousVersion string, commits []*object.Commit, modules []ChangelogModule) (Changelog, error) {
+	type key struct {
+		group  string
+		module string
+	}
+	entries := make(map[key][]ChangelogEntry)
+	for _, commit := range commits {
+		if commit.NumParents() > 1 {
+			continue
+		}
+		c, ok := ParseConventionalCommit(commit.Hash.String(), commit.Message)
+		if !ok {
+			c = ConventionalCommit{
+				Hash:        commit.Hash.String(),
+				Type:        changelogOtherType,
+				Description: strings.TrimSpace(strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]),
+			}
+		}
+		group := c.Type
+		if c.Breaking {
+			group = "breaking"
+		}
+		known := false
+		for _, g := range changelogGroups {
+			if g.Type == group {
+				known = true
+				break
+			}
+		}
+		if !known {
+			group = changelogOtherType
+		}
+
+		files, err := changedFiles(commit)
+		if err != nil {
+			return Changelog{}, err
+		}
+		entry := ChangelogEntry{
+			Hash:        c.Hash,
+			ShortHash:   c.Hash[:7],
+			Type:        c.Type,
+			Scope:       c.Scope,
+			Description: c.Description,
+			Breaking:    c.Breaking,
+		}
+		//commit is listed under modules it changes, or out of any module if it does not change any of them
+		seen := make(map[string]struct{})
+		for _, file := range files {
+			m, ok := moduleOfFile(modules, file)
+			if !ok {
+				continue
+			}
+			if _, ok := seen[m.Name]; ok {
+				continue
+			}
+			seen[m.Name] = struct{}{}
+			k := key{group: group, module: m.Name}
+			entries[k] = append(entries[k], entry)
+		}
+		if len(seen) == 0 {
+			k := key{group: group}
+			entries[k] = append(entries[k], entry)
+		}
+	}
+
+	//modules are listed in order of project config, files which do not belong to any module are listed first
+	moduleOrder := append([]ChangelogModule{{}}, modules...)
+	changelog := Changelog{
+		Version:         version,
+		PreviousVersion: previousVersion,
+		Date:            time.Now().Format("2006-01-02"),
+		Groups:          make([]ChangelogGroup, 0),
+	}
+	for _, g := range changelogGroups {
+		group := ChangelogGroup{
+			Type:    g.Type,
+			Title:   g.Title,
+			Modules: make([]ChangelogModuleEntries, 0),
+		}
+		for _, m := range moduleOrder {
+			items, ok := entries[key{group: g.Type, module: m.Name}]
+			if !ok {
+				continue
+			}
+			group.Modules = append(group.Modules, ChangelogModuleEntries{
+				Name:    m.Name,
+				Path:    m.Path,
+				Entries: items,
+			})
+		}
+		if len(group.Modules) > 0 {
+			changelog.Groups = append(changelog.Groups, group)
+		}
+	}
+	return changelog, nil
+}
+
+//MergeChangelog puts section of new release on top of existing changelog, below its title if there is one
+func MergeChangelog(existing, section []byte) []byte {
+	section = append(bytes.TrimSpace(section), '\n')
+	existing = bytes.TrimSpace(existing)
+	if len(existing) == 0 {
+		return section
+	}
+	var buf bytes.Buffer
+	if bytes.HasPrefix(existing, []byte("# ")) {
+		lines := bytes.SplitN(existing, []byte("\n"), 2)
+		buf.Write(lines[0])
+		buf.WriteString("\n\n")
+		existing = nil
+		if len(lines) > 1 {
+			existing = bytes.TrimSpace(lines[1])
+		}
+	}
+	buf.Write(section)
+	if len(existing) > 0 {
+		buf.WriteString("\n")
+		buf.Write(existing)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
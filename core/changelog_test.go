@@ -0,0 +1,57 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestMergeChangelog(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		section  string
+		want     string
+	}{
+		{
+			name:     "no changelog",
+			existing: "",
+			section:  "## 1.0.0\n\n- feat: a\n\n",
+			want:     "## 1.0.0\n\n- feat: a\n",
+		},
+		{
+			name:     "blank changelog",
+			existing: "\n  \n",
+			section:  "## 1.0.0\n- feat: a",
+			want:     "## 1.0.0\n- feat: a\n",
+		},
+		{
+			name:     "changelog without title",
+			existing: "## 1.0.0\n\n- feat: a\n",
+			section:  "## 1.1.0\n\n- feat: b\n",
+			want:     "## 1.1.0\n\n- feat: b\n\n## 1.0.0\n\n- feat: a\n",
+		},
+		{
+			name:     "section is below title",
+			existing: "# Changelog\n\n## 1.0.0\n\n- feat: a\n",
+			section:  "## 1.1.0\n\n- feat: b\n",
+			want:     "# Changelog\n\n## 1.1.0\n\n- feat: b\n\n## 1.0.0\n\n- feat: a\n",
+		},
+		{
+			name:     "title only",
+			existing: "# Changelog\n",
+			section:  "## 1.0.0\n\n- feat: a\n",
+			want:     "# Changelog\n\n## 1.0.0\n\n- feat: a\n",
+		},
+		{
+			name:     "heading of release is not title",
+			existing: "\n## 1.0.0\n- feat: a",
+			section:  "## 1.0.1\n- fix: b",
+			want:     "## 1.0.1\n- fix: b\n\n## 1.0.0\n- feat: a\n",
+		},
+	}
+	for _, tt := range tests {
+		got := string(MergeChangelog([]byte(tt.existing), []byte(tt.section)))
+		if got != tt.want {
+			t.Errorf("%s: MergeChangelog = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
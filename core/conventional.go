@@ -0,0 +1,103 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+)
+
+//VersionBump is the part of version that must be increased
+type VersionBump int
+
+const (
+	BumpNone VersionBump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b VersionBump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+	return "none"
+}
+
+//ConventionalCommit is a commit message that follows https://www.conventionalcommits.org
+type ConventionalCommit struct {
+	Hash        string
+	Type        string
+	Scope       string
+	Description string
+	Body        string
+	Breaking    bool
+}
+
+var conventionalHeader = regexp.MustCompile(`^([A-Za-z]+)(\(([^()]*)\))?(!)?:\s+(.+)$`)
+
+//ParseConventionalCommit parses message of commit, false is returned if header does not follow the specification
+func ParseConventionalCommit(hash, msg string) (ConventionalCommit, bool) {
+	msg = strings.TrimSpace(msg)
+	lines := strings.SplitN(msg, "\n", 2)
+	m := conventionalHeader.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if m == nil {
+		return ConventionalCommit{}, false
+	}
+	c := ConventionalCommit{
+		Hash:        hash,
+		Type:        strings.ToLower(m[1]),
+		Scope:       m[3],
+		Description: strings.TrimSpace(m[5]),
+		Breaking:    m[4] == "!",
+	}
+	if len(lines) > 1 {
+		c.Body = strings.TrimSpace(lines[1])
+	}
+	for _, line := range strings.Split(c.Body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			c.Breaking = true
+		}
+	}
+	return c, true
+}
+
+//Bump returns the part of version that is increased by this commit
+func (c ConventionalCommit) Bump() VersionBump {
+	if c.Breaking {
+		return BumpMajor
+	}
+	switch c.Type {
+	case "feat":
+		return BumpMinor
+	case "fix", "perf":
+		return BumpPatch
+	}
+	return BumpNone
+}
+
+//DetectBump returns the highest bump of commits
+func DetectBump(commits []ConventionalCommit) VersionBump {
+	bump := BumpNone
+	for _, c := range commits {
+		if b := c.Bump(); b > bump {
+			bump = b
+		}
+	}
+	return bump
+}
+
+//Bump increases version by the given part
+func (v *Version) Bump(b VersionBump) {
+	switch b {
+	case BumpMajor:
+		v.NextMajor()
+	case BumpMinor:
+		v.NextMinor()
+	case BumpPatch:
+		v.NextPatch()
+	}
+}
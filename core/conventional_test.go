@@ -0,0 +1,144 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		msg  string
+		ok   bool
+		want ConventionalCommit
+		bump VersionBump
+	}{
+		{
+			msg:  "feat: add pump --auto",
+			ok:   true,
+			want: ConventionalCommit{Type: "feat", Description: "add pump --auto"},
+			bump: BumpMinor,
+		},
+		{
+			msg:  "fix(publish): retry upload\n\nupload is retried on 503",
+			ok:   true,
+			want: ConventionalCommit{Type: "fix", Scope: "publish", Description: "retry upload", Body: "upload is retried on 503"},
+			bump: BumpPatch,
+		},
+		{
+			msg:  "perf(core)!: drop cache",
+			ok:   true,
+			want: ConventionalCommit{Type: "perf", Scope: "core", Description: "drop cache", Breaking: true},
+			bump: BumpMajor,
+		},
+		{
+			msg:  "feat!: remove v1 api",
+			ok:   true,
+			want: ConventionalCommit{Type: "feat", Description: "remove v1 api", Breaking: true},
+			bump: BumpMajor,
+		},
+		{
+			msg: "refactor: rename config\n\nBREAKING CHANGE: key build_mode is renamed to mode",
+			ok:  true,
+			want: ConventionalCommit{Type: "refactor", Description: "rename config",
+				Body: "BREAKING CHANGE: key build_mode is renamed to mode", Breaking: true},
+			bump: BumpMajor,
+		},
+		{
+			msg: "fix: parse pom\n\nRefs: #12\nBREAKING-CHANGE: revision is required",
+			ok:  true,
+			want: ConventionalCommit{Type: "fix", Description: "parse pom",
+				Body: "Refs: #12\nBREAKING-CHANGE: revision is required", Breaking: true},
+			bump: BumpMajor,
+		},
+		{
+			//breaking change must be a footer token, mentioning it in text is not enough
+			msg:  "docs: explain breaking change: policy",
+			ok:   true,
+			want: ConventionalCommit{Type: "docs", Description: "explain breaking change: policy"},
+			bump: BumpNone,
+		},
+		{
+			msg:  "Feat(UI): Upper case type",
+			ok:   true,
+			want: ConventionalCommit{Type: "feat", Scope: "UI", Description: "Upper case type"},
+			bump: BumpMinor,
+		},
+		{
+			msg:  "chore(deps): bump yaml",
+			ok:   true,
+			want: ConventionalCommit{Type: "chore", Scope: "deps", Description: "bump yaml"},
+			bump: BumpNone,
+		},
+		{msg: "Merge branch 'develop'", ok: false},
+		{msg: "increasing version to 1.0.1", ok: false},
+		{msg: "feat:missing space", ok: false},
+		{msg: "feat(a)(b): two scopes", ok: false},
+		{msg: "fix: ", ok: false},
+		{msg: "", ok: false},
+	}
+	for _, tt := range tests {
+		c, ok := ParseConventionalCommit("abc", tt.msg)
+		if ok != tt.ok {
+			t.Errorf("ParseConventionalCommit(%q) ok = %v, want %v", tt.msg, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		tt.want.Hash = "abc"
+		if c != tt.want {
+			t.Errorf("ParseConventionalCommit(%q) = %+v, want %+v", tt.msg, c, tt.want)
+		}
+		if b := c.Bump(); b != tt.bump {
+			t.Errorf("bump of %q = %s, want %s", tt.msg, b, tt.bump)
+		}
+	}
+}
+
+func TestDetectBump(t *testing.T) {
+	commit := func(msg string) ConventionalCommit {
+		c, ok := ParseConventionalCommit("", msg)
+		if !ok {
+			t.Fatalf("%q is not a conventional commit", msg)
+		}
+		return c
+	}
+	tests := []struct {
+		name    string
+		commits []ConventionalCommit
+		want    VersionBump
+	}{
+		{name: "no commits", commits: nil, want: BumpNone},
+		{name: "chores only", commits: []ConventionalCommit{commit("chore: a"), commit("docs: b")}, want: BumpNone},
+		{name: "fix", commits: []ConventionalCommit{commit("chore: a"), commit("fix: b")}, want: BumpPatch},
+		{name: "feat wins over fix", commits: []ConventionalCommit{commit("fix: a"), commit("feat: b"), commit("fix: c")},
+			want: BumpMinor},
+		{name: "breaking wins", commits: []ConventionalCommit{commit("feat: a"), commit("chore!: b")}, want: BumpMajor},
+		{name: "breaking footer wins", commits: []ConventionalCommit{commit("fix: a\n\nBREAKING CHANGE: b"), commit("feat: c")},
+			want: BumpMajor},
+	}
+	for _, tt := range tests {
+		if got := DetectBump(tt.commits); got != tt.want {
+			t.Errorf("%s: DetectBump = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		input string
+		bump  VersionBump
+		want  string
+	}{
+		{input: "1.2.3", bump: BumpNone, want: "1.2.3"},
+		{input: "1.2.3", bump: BumpPatch, want: "1.2.4"},
+		{input: "1.2.3", bump: BumpMinor, want: "1.3.0"},
+		{input: "1.2.3", bump: BumpMajor, want: "2.0.0"},
+	}
+	for _, tt := range tests {
+		v := mustParse(t, tt.input)
+		v.Bump(tt.bump)
+		if got := v.String(); got != tt.want {
+			t.Errorf("%s bumped by %s = %s, want %s", tt.input, tt.bump, got, tt.want)
+		}
+	}
+}
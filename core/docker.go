@@ -11,8 +11,8 @@ import (
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/locngoxuan/buildpack/config"
-	"github.com/locngoxuan/buildpack/utils"
 	"io"
+	"log"
 	"os"
 	"strings"
 )
@@ -39,7 +39,7 @@ func (c *DockerClient) ImageExist(ctx context.Context, imageRef string) (bool, [
 	if !strings.Contains(imageRef, ":") {
 		imageRef = fmt.Sprintf("%s:latest", imageRef)
 	}
-	args := filters.NewArgs(filters.KeyValuePair{"reference", imageRef})
+	args := filters.NewArgs(filters.KeyValuePair{Key: "reference", Value: imageRef})
 	images, err := c.Client.ImageList(ctx, types.ImageListOptions{Filters: args})
 	if err != nil {
 		return false, nil, err
@@ -80,15 +80,38 @@ func (c *DockerClient) BuildImageWithOpts(ctx context.Context, tarFile string, o
 		return types.ImageBuildResponse{}, err
 	}
 
+	if opt.AuthConfigs == nil {
+		opt.AuthConfigs = registryAuthConfigs(c.Registries)
+	}
+	return c.Client.ImageBuild(ctx, dockerBuildContext, opt)
+}
+
+//registryAuthConfigs returns credentials of registries that base images may be pulled from.
+//Build does not need every registry, then a registry whose secret can not be resolved is skipped with a warning
+//instead of failing the build
+func registryAuthConfigs(registries []config.DockerRegistry) map[string]types.AuthConfig {
 	authConfigs := make(map[string]types.AuthConfig)
-	for _, registry := range c.Registries {
+	for _, registry := range registries {
+		if strings.TrimSpace(registry.Username) == "" && strings.TrimSpace(registry.Password) == "" {
+			continue
+		}
+		username, err := config.ResolveSecret(registry.Username)
+		if err != nil {
+			log.Printf("WARN: skip credential of docker registry %s: %v", registry.Address, err)
+			continue
+		}
+		password, err := config.ResolveSecret(registry.Password)
+		if err != nil {
+			log.Printf("WARN: skip credential of docker registry %s: %v", registry.Address, err)
+			continue
+		}
 		authConfigs[registry.Address] = types.AuthConfig{
-			Username: utils.ReadEnvVariableIfHas(registry.Username),
-			Password: utils.ReadEnvVariableIfHas(registry.Password),
+			Username:      username,
+			Password:      password,
+			ServerAddress: registry.Address,
 		}
 	}
-
-	return c.Client.ImageBuild(ctx, dockerBuildContext, opt)
+	return authConfigs
 }
 
 func (c *DockerClient) TagImage(ctx context.Context, src, dest string) error {
@@ -102,15 +125,23 @@ func (c *DockerClient) DeployImage(ctx context.Context, username, password, imag
 	}
 	opt := types.ImagePushOptions{
 		RegistryAuth: a,
-		All:          true,
+		All:          false,
 	}
 	return c.Client.ImagePush(ctx, image, opt)
 }
 
 func auth(username, password string) (string, error) {
+	username, err := config.ResolveSecret(username)
+	if err != nil {
+		return "", err
+	}
+	password, err = config.ResolveSecret(password)
+	if err != nil {
+		return "", err
+	}
 	authConfig := types.AuthConfig{
-		Username: utils.ReadEnvVariableIfHas(username),
-		Password: utils.ReadEnvVariableIfHas(password),
+		Username: username,
+		Password: password,
 	}
 	encodedJSON, err := json.Marshal(authConfig)
 	if err != nil {
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/locngoxuan/buildpack/config"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRegistryAuthConfigs(t *testing.T) {
+	_ = os.Unsetenv("BPP_TEST_UNSET")
+	tests := []struct {
+		name       string
+		registries []config.DockerRegistry
+		want       map[string]types.AuthConfig
+	}{
+		{
+			name: "plain credentials",
+			registries: []config.DockerRegistry{
+				{Id: "hub", Address: "docker.io", Username: "u", Password: "p"},
+			},
+			want: map[string]types.AuthConfig{
+				"docker.io": {Username: "u", Password: "p", ServerAddress: "docker.io"},
+			},
+		},
+		{
+			name: "registry without credentials",
+			registries: []config.DockerRegistry{
+				{Id: "local", Address: "localhost:5000"},
+			},
+			want: map[string]types.AuthConfig{},
+		},
+		{
+			name: "unresolved secret is skipped",
+			registries: []config.DockerRegistry{
+				{Id: "hub", Address: "docker.io", Username: "u", Password: "p"},
+				{Id: "ghcr", Address: "ghcr.io", Username: "u", Password: "env:BPP_TEST_UNSET"},
+				{Id: "quay", Address: "quay.io", Username: "env:BPP_TEST_UNSET", Password: "p"},
+			},
+			want: map[string]types.AuthConfig{
+				"docker.io": {Username: "u", Password: "p", ServerAddress: "docker.io"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := registryAuthConfigs(tt.registries); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: registryAuthConfigs = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
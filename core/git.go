@@ -3,34 +3,54 @@ package core
 import "C"
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
 	gitconfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/locngoxuan/buildpack/config"
 	"github.com/locngoxuan/buildpack/utils"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
-func signature() *object.Signature {
+const defaultAuthorName = "system"
+
+func (c *GitClient) signature() *object.Signature {
+	name := utils.ReadEnvVariableIfHas(c.Author.Name)
+	if utils.IsStringEmpty(name) {
+		name = defaultAuthorName
+	}
 	return &object.Signature{
-		Name: "system",
-		When: time.Now(),
+		Name:  name,
+		Email: utils.ReadEnvVariableIfHas(c.Author.Email),
+		When:  time.Now(),
 	}
 }
 
+const pushRemoteName = "update-code"
+
+//ErrNonFastForward is returned if remote rejects push since remote ref has commits which are not in local repository
+var ErrNonFastForward = errors.New("remote has changes which are not in local repository")
+
 type GitOption struct {
 	WorkDir       string
 	Branch        string
 	RemoteAddress string
 	config.GitCredential
+	Author  config.GitAuthor
+	Signing config.GitSigning
 }
 
 type GitClient struct {
@@ -40,7 +60,7 @@ type GitClient struct {
 }
 
 func (c *GitClient) auth() (transport.AuthMethod, error) {
-	return authWithCred(c.GitCredential)
+	return authWithCred(c.RemoteAddress, c.GitCredential)
 }
 
 func (c *GitClient) CloneIntoMemory(ctx context.Context) error {
@@ -62,31 +82,108 @@ func (c *GitClient) CloneIntoMemory(ctx context.Context) error {
 	return nil
 }
 
-func (c *GitClient) WriteSingleFile(data []byte, file, commitMsg string) error {
-	wt, err := c.Repo.Worktree()
+//OpenLocal opens repository which contains working directory instead of cloning it
+func (c *GitClient) OpenLocal() error {
+	var err error
+	c.Repo, err = git.PlainOpenWithOptions(c.WorkDir, &git.PlainOpenOptions{
+		DetectDotGit: true,
+	})
 	if err != nil {
 		return err
 	}
-	f, err := wt.Filesystem.OpenFile(file, os.O_RDWR|os.O_TRUNC, 0755)
+	head, err := c.Repo.Head()
 	if err != nil {
 		return err
 	}
+	if !head.Name().IsBranch() {
+		return fmt.Errorf("HEAD of repository is not a branch")
+	}
+	c.ReferenceName = head.Name()
+	c.Branch = head.Name().Short()
+	return nil
+}
+
+//HeadCommit returns hash of HEAD of repository which contains dir
+func HeadCommit(dir string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{
+		DetectDotGit: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+//IsClean returns true if worktree does not have any change of tracked files.
+//Untracked files are ignored since they are neither committed nor changed by pumping
+func (c *GitClient) IsClean() (bool, error) {
+	wt, err := c.Repo.Worktree()
+	if err != nil {
+		return false, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return false, err
+	}
+	for _, s := range status {
+		if s.Staging == git.Untracked && s.Worktree == git.Untracked {
+			continue
+		}
+		if s.Staging != git.Unmodified || s.Worktree != git.Unmodified {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+//ReadFile reads file from worktree, nil is returned if file does not exist
+func (c *GitClient) ReadFile(file string) ([]byte, error) {
+	wt, err := c.Repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	f, err := wt.Filesystem.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
 	defer func() {
 		_ = f.Close()
 	}()
-	_, err = f.Write(data)
+	return ioutil.ReadAll(f)
+}
+
+func (c *GitClient) WriteSingleFile(data []byte, file, commitMsg string) error {
+	return c.WriteFiles(map[string][]byte{file: data}, commitMsg)
+}
+
+//WriteFiles writes files into worktree then commits them together
+func (c *GitClient) WriteFiles(files map[string][]byte, commitMsg string) error {
+	s, err := newSigner(c.Signing)
 	if err != nil {
 		return err
 	}
-	_, err = wt.Add(file)
+	wt, err := c.Repo.Worktree()
 	if err != nil {
 		return err
 	}
-	_, err = wt.Commit(commitMsg, &git.CommitOptions{
-		All:       true,
-		Author:    signature(),
-		Committer: signature(),
-	})
+	for file, data := range files {
+		err = writeWorktreeFile(wt, file, data)
+		if err != nil {
+			return err
+		}
+		_, err = wt.Add(file)
+		if err != nil {
+			return err
+		}
+	}
+	err = c.commit(wt, s, commitMsg)
 	if err != nil {
 		return err
 	}
@@ -101,23 +198,150 @@ func (c *GitClient) WriteSingleFile(data []byte, file, commitMsg string) error {
 	return nil
 }
 
-func (c *GitClient) Push(ctx context.Context) error {
+//commit commits staged changes of worktree and signs it if signer is given, parents are HEAD if none is given
+func (c *GitClient) commit(wt *git.Worktree, s signer, msg string, parents ...plumbing.Hash) error {
+	_, err := wt.Commit(msg, &git.CommitOptions{
+		All:       true,
+		Author:    c.signature(),
+		Committer: c.signature(),
+		Parents:   parents,
+	})
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+	return c.signHead(s)
+}
+
+func writeWorktreeFile(wt *git.Worktree, file string, data []byte) error {
+	f, err := wt.Filesystem.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = f.Write(data)
+	return err
+}
+
+//pushRefSpecs pushes references to remote address of option, or to origin if address is not configured
+func (c *GitClient) pushRefSpecs(ctx context.Context, specs ...gitconfig.RefSpec) error {
 	auth, err := c.auth()
 	if err != nil {
 		return err
 	}
+	remoteName := git.DefaultRemoteName
+	if !utils.IsStringEmpty(c.RemoteAddress) {
+		_ = c.Repo.DeleteRemote(pushRemoteName)
+		remote, err := c.Repo.CreateRemote(&gitconfig.RemoteConfig{
+			Name: pushRemoteName,
+			URLs: []string{c.RemoteAddress},
+		})
+		if err != nil {
+			return fmt.Errorf("can not create anonymouse remote %v", err)
+		}
+		//remote is removed, then config of local repository is kept as it was
+		defer func() {
+			_ = c.Repo.DeleteRemote(pushRemoteName)
+		}()
+		remoteName = remote.Config().Name
+	}
+	//push is never forced, then commits which are pushed concurrently by others are not overwritten
 	err = c.Repo.PushContext(ctx, &git.PushOptions{
-		RefSpecs: []gitconfig.RefSpec{
-			gitconfig.RefSpec(c.ReferenceName + ":" + c.ReferenceName),
-		},
-		Force:    true,
-		Progress: os.Stdout,
-		Auth:     auth,
+		RemoteName: remoteName,
+		RefSpecs:   specs,
+		Progress:   os.Stdout,
+		Auth:       auth,
 	})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	if isNonFastForward(err) {
+		return fmt.Errorf("%w: %v", ErrNonFastForward, err)
+	}
+	return err
+}
+
+const reportStatusErrorPrefix = "command error on "
+
+//rejectedStatuses are report statuses of remote for a reference which has been changed since it was fetched
+var rejectedStatuses = map[string]struct{}{
+	"non-fast-forward":                {},
+	"fetch first":                     {},
+	server.ErrUpdateReference.Error(): {},
+}
+
+//isNonFastForward detects rejection of push either by local check of go-git or by report status of remote.
+//go-git v5.2.0 formats both of them without wrapping git.ErrNonFastForwardUpdate, then their formats are parsed:
+//  non-fast-forward update: <ref>
+//  command error on <ref>: <status>
+func isNonFastForward(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, git.ErrNonFastForwardUpdate) || errors.Is(err, server.ErrUpdateReference) {
+		return true
+	}
+	msg := err.Error()
+	if strings.HasPrefix(msg, git.ErrNonFastForwardUpdate.Error()+": ") {
+		return true
+	}
+	if !strings.HasPrefix(msg, reportStatusErrorPrefix) {
+		return false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(msg, reportStatusErrorPrefix), ": ", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	_, ok := rejectedStatuses[strings.TrimSpace(parts[1])]
+	return ok
+}
+
+func (c *GitClient) Push(ctx context.Context) error {
+	return c.pushRefSpecs(ctx, gitconfig.RefSpec(c.ReferenceName+":"+c.ReferenceName))
+}
+
+//HeadHash returns hash of commit which HEAD points to
+func (c *GitClient) HeadHash() (string, error) {
+	h, err := c.Repo.Head()
 	if err != nil {
-		return err
+		return "", err
 	}
-	return nil
+	return h.Hash().String(), nil
+}
+
+//BranchHash returns hash of commit which local branch points to
+func (c *GitClient) BranchHash(name string) (string, error) {
+	ref, err := c.Repo.Reference(plumbing.NewBranchReferenceName(name), true)
+	if err != nil {
+		return "", err
+	}
+	return ref.Hash().String(), nil
+}
+
+func (c *GitClient) TagExists(name string) (bool, error) {
+	return tagExists(name, c.Repo)
+}
+
+func (c *GitClient) BranchExists(name string) (bool, error) {
+	_, err := c.Repo.Reference(plumbing.NewBranchReferenceName(name), false)
+	if err == plumbing.ErrReferenceNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+//DeleteRemoteTag deletes tag on remote, local tag is kept
+func (c *GitClient) DeleteRemoteTag(ctx context.Context, name string) error {
+	return c.pushRefSpecs(ctx, gitconfig.RefSpec(":"+plumbing.NewTagReferenceName(name)))
+}
+
+//DeleteRemoteBranch deletes branch on remote, local branch is kept
+func (c *GitClient) DeleteRemoteBranch(ctx context.Context, name string) error {
+	return c.pushRefSpecs(ctx, gitconfig.RefSpec(":"+plumbing.NewBranchReferenceName(name)))
 }
 
 func tagExists(tag string, r *git.Repository) (bool, error) {
@@ -135,109 +359,194 @@ func tagExists(tag string, r *git.Repository) (bool, error) {
 	return err != nil, nil
 }
 
-func (c *GitClient) Tag(ctx context.Context, version string) error {
-	exist, err := tagExists(version, c.Repo)
+//CreateTag creates annotated tag at HEAD without pushing it
+func (c *GitClient) CreateTag(name, message string) error {
+	exist, err := tagExists(name, c.Repo)
 	if err != nil {
 		return err
 	}
 	if exist {
 		return fmt.Errorf("tag was found")
 	}
+	s, err := newSigner(c.Signing)
+	if err != nil {
+		return err
+	}
 
 	h, err := c.Repo.Head()
 	if err != nil {
 		return err
 	}
-	_, err = c.Repo.CreateTag(version, h.Hash(), &git.CreateTagOptions{
-		Tagger:  signature(),
-		Message: "v" + version,
+	_, err = c.Repo.CreateTag(name, h.Hash(), &git.CreateTagOptions{
+		Tagger:  c.signature(),
+		Message: message,
 	})
-
-	if err != nil {
+	if err != nil || s == nil {
 		return err
 	}
-	auth, err := c.auth()
+	return c.signTag(s, name)
+}
+
+func (c *GitClient) PushTag(ctx context.Context, name string) error {
+	ref := plumbing.NewTagReferenceName(name)
+	return c.pushRefSpecs(ctx, gitconfig.RefSpec(ref+":"+ref))
+}
+
+func (c *GitClient) Tag(ctx context.Context, name, message string) error {
+	err := c.CreateTag(name, message)
 	if err != nil {
 		return err
 	}
+	return c.PushTag(ctx, name)
+}
 
-	_ = c.Repo.DeleteRemote("update-code")
-	remote, err := c.Repo.CreateRemote(&gitconfig.RemoteConfig{
-		Name: "update-code",
-		URLs: []string{c.RemoteAddress},
-	})
+//versionTags returns versions of tags keyed by hash of commit which tag points to, tags that are not a version are ignored.
+//If prefix is not empty, then only tags of that prefix are taken, e.g. prefix lib@ takes lib@1.0.0 as version 1.0.0
+func (c *GitClient) versionTags(prefix string) (map[plumbing.Hash]Version, error) {
+	refs, err := c.Repo.Tags()
 	if err != nil {
-		return fmt.Errorf("can not create anonymouse remote %v", err)
+		return nil, err
 	}
-	po := &git.PushOptions{
-		RemoteName: remote.Config().Name,
-		Progress:   os.Stdout,
-		RefSpecs: []gitconfig.RefSpec{
-			gitconfig.RefSpec("refs/tags/*:refs/tags/*"),
-		},
-		Auth: auth,
+	result := make(map[plumbing.Hash]Version)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if prefix == "" {
+			name = strings.TrimPrefix(name, "v")
+		} else if strings.HasPrefix(name, prefix) {
+			name = strings.TrimPrefix(name, prefix)
+		} else {
+			return nil
+		}
+		v, err := Parse(name)
+		if err != nil {
+			return nil
+		}
+		hash := ref.Hash()
+		//annotated tag points to a tag object instead of commit
+		tag, err := c.Repo.TagObject(hash)
+		if err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				return nil
+			}
+			hash = commit.Hash
+		}
+		if existing, ok := result[hash]; !ok || existing.Compare(v) < 0 {
+			result[hash] = v
+		}
+		return nil
+	})
+	if err != nil && err != storer.ErrStop {
+		return nil, err
 	}
-	return c.Repo.Push(po)
+	return result, nil
 }
 
-func authWithCred(cred config.GitCredential) (transport.AuthMethod, error) {
-	switch cred.Type {
-	case config.CredentialToken:
-		return &http.BasicAuth{
-			Username: "token",
-			Password: utils.ReadEnvVariableIfHas(cred.AccessToken),
-		}, nil
-	case config.CredentialAccount:
-		return &http.BasicAuth{
-			Username: utils.ReadEnvVariableIfHas(cred.Username),
-			Password: utils.ReadEnvVariableIfHas(cred.Password),
-		}, nil
+//Versions returns versions of tags of prefix, if many versions tag the same commit then only the highest one is taken
+func (c *GitClient) Versions(prefix string) ([]Version, error) {
+	tags, err := c.versionTags(prefix)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]Version, 0, len(tags))
+	for _, v := range tags {
+		versions = append(versions, v)
 	}
-	return nil, fmt.Errorf("can not recognize credential type")
+	return versions, nil
 }
 
-func (c *GitClient) CreateNewBranch(branchName string) error {
-	newBranchName := plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", branchName))
-
-	wt, err := c.Repo.Worktree()
+//CommitsSinceLastVersion walks history from head until commits which are tagged by a version of tagPrefix.
+//It returns the highest version of those tags, or nil if there is none, and commits from newest to oldest.
+//If path is not empty, then only commits that change files under path are returned
+func (c *GitClient) CommitsSinceLastVersion(tagPrefix, path string) (*Version, []*object.Commit, error) {
+	tags, err := c.versionTags(tagPrefix)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	err = wt.Checkout(&git.CheckoutOptions{
-		Branch: newBranchName,
-		Force:  true,
-		Create: true,
-		Keep:   true,
-	})
+	head, err := c.Repo.Head()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	auth, err := c.auth()
+	headCommit, err := c.Repo.CommitObject(head.Hash())
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	err = c.Repo.Push(&git.PushOptions{
-		RefSpecs: []gitconfig.RefSpec{
-			gitconfig.RefSpec(newBranchName + ":" + newBranchName),
-		},
-		Progress: os.Stdout,
-		Auth:     auth,
-		Force:    true,
+
+	var last *Version
+	commits := make([]*object.Commit, 0)
+	visited := make(map[plumbing.Hash]struct{})
+	queue := []*object.Commit{headCommit}
+	for len(queue) > 0 {
+		commit := queue[0]
+		queue = queue[1:]
+		if _, ok := visited[commit.Hash]; ok {
+			continue
+		}
+		visited[commit.Hash] = struct{}{}
+		if v, ok := tags[commit.Hash]; ok {
+			if last == nil || last.Compare(v) < 0 {
+				tagged := v
+				last = &tagged
+			}
+			continue
+		}
+		touched, err := commitTouchesPath(commit, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if touched {
+			commits = append(commits, commit)
+		}
+		err = commit.Parents().ForEach(func(parent *object.Commit) error {
+			queue = append(queue, parent)
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Committer.When.After(commits[j].Committer.When)
 	})
+	return last, commits, nil
+}
+
+func commitTouchesPath(commit *object.Commit, path string) (bool, error) {
+	path = strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+	if path == "" || path == "." {
+		return true, nil
+	}
+	files, err := changedFiles(commit)
 	if err != nil {
-		return err
+		return false, err
 	}
+	for _, file := range files {
+		if file == path || strings.HasPrefix(file, path+"/") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
-	oldBranchName := plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", c.Branch))
-	err = wt.Checkout(&git.CheckoutOptions{
-		Branch: oldBranchName,
-		Force:  true,
-		Create: false,
-		Keep:   false,
-	})
+//CreateBranch creates branch at HEAD without checking it out
+func (c *GitClient) CreateBranch(branchName string) error {
+	h, err := c.Repo.Head()
 	if err != nil {
 		return err
 	}
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(branchName), h.Hash())
+	return c.Repo.Storer.SetReference(ref)
+}
 
-	return nil
+func (c *GitClient) PushBranch(ctx context.Context, branchName string) error {
+	ref := plumbing.NewBranchReferenceName(branchName)
+	return c.pushRefSpecs(ctx, gitconfig.RefSpec(ref+":"+ref))
+}
+
+func (c *GitClient) CreateNewBranch(ctx context.Context, branchName string) error {
+	err := c.CreateBranch(branchName)
+	if err != nil {
+		return err
+	}
+	return c.PushBranch(ctx, branchName)
 }
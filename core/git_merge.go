@@ -0,0 +1,169 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"strings"
+)
+
+//fileHash returns hash of file in tree, zero hash is returned if file does not exist
+func fileHash(tree *object.Tree, file string) (plumbing.Hash, error) {
+	f, err := tree.File(file)
+	if err == object.ErrFileNotFound || err == object.ErrDirectoryNotFound || err == object.ErrEntryNotFound {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return f.Hash, nil
+}
+
+//MergeInto merges HEAD into target branch which is fetched from remote, then HEAD is moved to target branch.
+//Files are merged as a whole: a file changed only by HEAD is taken, a file changed by both sides must have the same content.
+//Files in keep always have content of target branch, e.g. version of target must not be replaced by version of HEAD
+func (c *GitClient) MergeInto(ctx context.Context, target string, keep []string, msg string) error {
+	auth, err := c.auth()
+	if err != nil {
+		return err
+	}
+	targetRef := plumbing.NewBranchReferenceName(target)
+	err = c.Repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("+%s:%s", targetRef, targetRef))},
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("fetch branch %s get error %v", target, err)
+	}
+
+	head, err := c.Repo.Head()
+	if err != nil {
+		return err
+	}
+	theirs, err := c.Repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	ref, err := c.Repo.Reference(targetRef, true)
+	if err != nil {
+		return err
+	}
+	ours, err := c.Repo.CommitObject(ref.Hash())
+	if err != nil {
+		return err
+	}
+	bases, err := theirs.MergeBase(ours)
+	if err != nil {
+		return err
+	}
+	if len(bases) == 0 {
+		return fmt.Errorf("%s and %s do not have common history", head.Name().Short(), target)
+	}
+
+	baseTree, err := bases[0].Tree()
+	if err != nil {
+		return err
+	}
+	theirTree, err := theirs.Tree()
+	if err != nil {
+		return err
+	}
+	ourTree, err := ours.Tree()
+	if err != nil {
+		return err
+	}
+	changes, err := object.DiffTree(baseTree, theirTree)
+	if err != nil {
+		return err
+	}
+	kept := make(map[string]struct{})
+	for _, file := range keep {
+		kept[file] = struct{}{}
+	}
+	taken := make([]string, 0)
+	conflicts := make([]string, 0)
+	for _, change := range changes {
+		file := change.To.Name
+		if file == "" {
+			file = change.From.Name
+		}
+		if _, ok := kept[file]; ok {
+			continue
+		}
+		baseHash, err := fileHash(baseTree, file)
+		if err != nil {
+			return err
+		}
+		ourHash, err := fileHash(ourTree, file)
+		if err != nil {
+			return err
+		}
+		theirHash, err := fileHash(theirTree, file)
+		if err != nil {
+			return err
+		}
+		switch ourHash {
+		case theirHash:
+		case baseHash:
+			taken = append(taken, file)
+		default:
+			conflicts = append(conflicts, file)
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%s can not be merged into %s since both changed %s",
+			head.Name().Short(), target, strings.Join(conflicts, ", "))
+	}
+
+	s, err := newSigner(c.Signing)
+	if err != nil {
+		return err
+	}
+	wt, err := c.Repo.Worktree()
+	if err != nil {
+		return err
+	}
+	err = wt.Checkout(&git.CheckoutOptions{
+		Branch: targetRef,
+		Force:  true,
+	})
+	if err != nil {
+		return err
+	}
+	for _, file := range taken {
+		f, err := theirTree.File(file)
+		if err == object.ErrFileNotFound {
+			_, err = wt.Remove(file)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		content, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		err = writeWorktreeFile(wt, file, []byte(content))
+		if err != nil {
+			return err
+		}
+		_, err = wt.Add(file)
+		if err != nil {
+			return err
+		}
+	}
+	err = c.commit(wt, s, msg, ours.Hash, theirs.Hash)
+	if err != nil {
+		return err
+	}
+	c.ReferenceName = targetRef
+	c.Branch = target
+	return nil
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/utils"
+	"golang.org/x/crypto/openpgp"
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+//signer creates an armored detached signature of encoded git object
+type signer interface {
+	sign(data []byte) (string, error)
+}
+
+type gpgSigner struct {
+	entity *openpgp.Entity
+}
+
+func (s gpgSigner) sign(data []byte) (string, error) {
+	var buf bytes.Buffer
+	err := openpgp.ArmoredDetachSign(&buf, s.entity, bytes.NewReader(data), nil)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+//sshSigner signs by ssh-keygen as git does with gpg.format=ssh
+type sshSigner struct {
+	key string
+}
+
+func (s sshSigner) sign(data []byte) (string, error) {
+	tmp, err := ioutil.TempFile("", "bpp-sign-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+		_ = os.Remove(tmp.Name() + ".sig")
+	}()
+	_, err = tmp.Write(data)
+	_ = tmp.Close()
+	if err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("ssh-keygen", "-Y", "sign", "-n", "git", "-f", s.key, tmp.Name())
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("ssh-keygen get error %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	sig, err := ioutil.ReadFile(tmp.Name() + ".sig")
+	if err != nil {
+		return "", err
+	}
+	return string(sig), nil
+}
+
+func newSigner(signing config.GitSigning) (signer, error) {
+	key, err := expandHome(utils.ReadEnvVariableIfHas(signing.Key))
+	if err != nil {
+		return nil, err
+	}
+	switch signing.Format {
+	case "":
+		return nil, nil
+	case config.SigningSsh:
+		return sshSigner{key: key}, nil
+	case config.SigningGpg:
+		f, err := os.Open(key)
+		if err != nil {
+			return nil, fmt.Errorf("read gpg key get error %v", err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		entities, err := openpgp.ReadArmoredKeyRing(f)
+		if err != nil {
+			return nil, fmt.Errorf("read gpg key get error %v", err)
+		}
+		if len(entities) == 0 || entities[0].PrivateKey == nil {
+			return nil, fmt.Errorf("gpg key %s does not contain private key", key)
+		}
+		entity := entities[0]
+		secret, err := config.ResolveSecret(signing.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		passphrase := []byte(secret)
+		if entity.PrivateKey.Encrypted {
+			err = entity.PrivateKey.Decrypt(passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("decrypt gpg key get error %v", err)
+			}
+		}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+				err = subkey.PrivateKey.Decrypt(passphrase)
+				if err != nil {
+					return nil, fmt.Errorf("decrypt gpg key get error %v", err)
+				}
+			}
+		}
+		return gpgSigner{entity: entity}, nil
+	}
+	return nil, fmt.Errorf("can not recognize signing format %s", signing.Format)
+}
+
+type signedObject interface {
+	EncodeWithoutSignature(o plumbing.EncodedObject) error
+	Encode(o plumbing.EncodedObject) error
+}
+
+//signObject stores a signed copy of git object and returns its hash, setSignature puts signature into the object
+func (c *GitClient) signObject(s signer, obj signedObject, setSignature func(sig string)) (plumbing.Hash, error) {
+	unsigned := &plumbing.MemoryObject{}
+	err := obj.EncodeWithoutSignature(unsigned)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	r, err := unsigned.Reader()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	sig, err := s.sign(data)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("sign git object get error %v", err)
+	}
+	setSignature(sig)
+	signed := c.Repo.Storer.NewEncodedObject()
+	err = obj.Encode(signed)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return c.Repo.Storer.SetEncodedObject(signed)
+}
+
+//signHead replaces commit of HEAD by signed one
+func (c *GitClient) signHead(s signer) error {
+	head, err := c.Repo.Head()
+	if err != nil {
+		return err
+	}
+	commit, err := c.Repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	hash, err := c.signObject(s, commit, func(sig string) {
+		commit.PGPSignature = sig
+	})
+	if err != nil {
+		return err
+	}
+	return c.Repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), hash))
+}
+
+//signTag replaces annotated tag by signed one
+func (c *GitClient) signTag(s signer, name string) error {
+	ref, err := c.Repo.Tag(name)
+	if err != nil {
+		return err
+	}
+	tag, err := c.Repo.TagObject(ref.Hash())
+	if err != nil {
+		return err
+	}
+	hash, err := c.signObject(s, tag, func(sig string) {
+		tag.PGPSignature = sig
+	})
+	if err != nil {
+		return err
+	}
+	return c.Repo.Storer.SetReference(plumbing.NewHashReference(ref.Name(), hash))
+}
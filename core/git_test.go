@@ -0,0 +1,129 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//reportStatusError returns error of push as go-git returns it for a report status of remote
+func reportStatusError(status string) error {
+	rs := packp.NewReportStatus()
+	rs.UnpackStatus = "ok"
+	rs.CommandStatuses = []*packp.CommandStatus{{
+		ReferenceName: plumbing.NewBranchReferenceName("main"),
+		Status:        status,
+	}}
+	return rs.Error()
+}
+
+func TestIsNonFastForward(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "no error", err: nil, want: false},
+		{name: "sentinel", err: git.ErrNonFastForwardUpdate, want: true},
+		{name: "wrapped sentinel", err: fmt.Errorf("pull get error %w", git.ErrNonFastForwardUpdate), want: true},
+		{name: "local check", err: fmt.Errorf("non-fast-forward update: refs/heads/main"), want: true},
+		{name: "update reference", err: server.ErrUpdateReference, want: true},
+		{name: "remote non-fast-forward", err: reportStatusError("non-fast-forward"), want: true},
+		{name: "remote fetch first", err: reportStatusError("fetch first"), want: true},
+		{name: "remote failed to update ref", err: reportStatusError("failed to update ref"), want: true},
+		{name: "remote hook declined", err: reportStatusError("pre-receive hook declined"), want: false},
+		{name: "ref name is not status", err: fmt.Errorf("command error on refs/heads/fetch first: hook declined"), want: false},
+		{name: "unpack error", err: fmt.Errorf("unpack error: non-fast-forward"), want: false},
+		{name: "already up to date", err: git.NoErrAlreadyUpToDate, want: false},
+		{name: "authentication", err: transport.ErrAuthenticationRequired, want: false},
+		{name: "text mentions non-fast-forward", err: errors.New("hook says: non-fast-forward is not allowed"), want: false},
+	}
+	for _, tt := range tests {
+		if got := isNonFastForward(tt.err); got != tt.want {
+			t.Errorf("%s: isNonFastForward(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsClean(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, dir string, wt *git.Worktree)
+		want   bool
+	}{
+		{name: "no change", change: func(t *testing.T, dir string, wt *git.Worktree) {}, want: true},
+		{name: "untracked file", change: func(t *testing.T, dir string, wt *git.Worktree) {
+			writeFile(t, filepath.Join(dir, "build.log"), "x")
+		}, want: true},
+		{name: "modified file", change: func(t *testing.T, dir string, wt *git.Worktree) {
+			writeFile(t, filepath.Join(dir, "Project.bpp"), "version: 1.0.1\n")
+		}, want: false},
+		{name: "deleted file", change: func(t *testing.T, dir string, wt *git.Worktree) {
+			if err := os.Remove(filepath.Join(dir, "Project.bpp")); err != nil {
+				t.Fatal(err)
+			}
+		}, want: false},
+		{name: "staged new file", change: func(t *testing.T, dir string, wt *git.Worktree) {
+			writeFile(t, filepath.Join(dir, "README.md"), "x")
+			if _, err := wt.Add("README.md"); err != nil {
+				t.Fatal(err)
+			}
+		}, want: false},
+	}
+	for _, tt := range tests {
+		dir, err := ioutil.TempDir("", "bpp-git")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		repo, err := git.PlainInit(dir, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wt, err := repo.Worktree()
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, "Project.bpp"), "version: 1.0.0\n")
+		if _, err = wt.Add("Project.bpp"); err != nil {
+			t.Fatal(err)
+		}
+		_, err = wt.Commit("init", &git.CommitOptions{
+			Author: &object.Signature{Name: "bpp", Email: "bpp@localhost", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.change(t, dir, wt)
+
+		c := &GitClient{GitOption: GitOption{WorkDir: dir}}
+		if err = c.OpenLocal(); err != nil {
+			t.Fatalf("%s: open repository get error %v", tt.name, err)
+		}
+		clean, err := c.IsClean()
+		if err != nil {
+			t.Fatalf("%s: IsClean get error %v", tt.name, err)
+		}
+		if clean != tt.want {
+			t.Errorf("%s: IsClean = %v, want %v", tt.name, clean, tt.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, file, content string) {
+	t.Helper()
+	err := ioutil.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -54,3 +54,111 @@ func ReadPOM(pomFile string) (POM, error) {
 	}
 	return pomProject, nil
 }
+
+//MavenMetadata represents maven-metadata.xml of both artifact level and snapshot version level
+type MavenMetadata struct {
+	XMLName      xml.Name        `xml:"metadata"`
+	ModelVersion string          `xml:"modelVersion,attr,omitempty"`
+	GroupId      string          `xml:"groupId"`
+	ArtifactId   string          `xml:"artifactId"`
+	Version      string          `xml:"version,omitempty"`
+	Versioning   MavenVersioning `xml:"versioning"`
+}
+
+type MavenVersioning struct {
+	Latest           string                 `xml:"latest,omitempty"`
+	Release          string                 `xml:"release,omitempty"`
+	Snapshot         *MavenSnapshot         `xml:"snapshot,omitempty"`
+	Versions         *MavenVersions         `xml:"versions,omitempty"`
+	LastUpdated      string                 `xml:"lastUpdated,omitempty"`
+	SnapshotVersions *MavenSnapshotVersions `xml:"snapshotVersions,omitempty"`
+}
+
+type MavenVersions struct {
+	Version []string `xml:"version"`
+}
+
+type MavenSnapshotVersions struct {
+	SnapshotVersion []MavenSnapshotVersion `xml:"snapshotVersion"`
+}
+
+type MavenSnapshot struct {
+	Timestamp   string `xml:"timestamp"`
+	BuildNumber int    `xml:"buildNumber"`
+}
+
+type MavenSnapshotVersion struct {
+	Classifier string `xml:"classifier,omitempty"`
+	Extension  string `xml:"extension"`
+	Value      string `xml:"value"`
+	Updated    string `xml:"updated"`
+}
+
+const (
+	MavenMetadataFile      = "maven-metadata.xml"
+	MavenLastUpdatedFormat = "20060102150405"
+	MavenTimestampFormat   = "20060102.150405"
+)
+
+func ParseMavenMetadata(data []byte) (MavenMetadata, error) {
+	var m MavenMetadata
+	err := xml.Unmarshal(data, &m)
+	if err != nil {
+		return m, fmt.Errorf("unmarshal maven metadata get error %v", err)
+	}
+	return m, nil
+}
+
+//AddVersion merges a deployed version into artifact level metadata
+func (m *MavenMetadata) AddVersion(version string, release bool, lastUpdated string) {
+	if m.Versioning.Versions == nil {
+		m.Versioning.Versions = &MavenVersions{}
+	}
+	found := false
+	for _, v := range m.Versioning.Versions.Version {
+		if v == version {
+			found = true
+			break
+		}
+	}
+	if !found {
+		m.Versioning.Versions.Version = append(m.Versioning.Versions.Version, version)
+	}
+	m.Versioning.Latest = version
+	if release {
+		m.Versioning.Release = version
+	}
+	m.Versioning.LastUpdated = lastUpdated
+}
+
+//AddSnapshotVersion merges a deployed file into snapshot version level metadata,
+//the older entry having same classifier and extension is replaced
+func (m *MavenMetadata) AddSnapshotVersion(sv MavenSnapshotVersion) {
+	if m.Versioning.SnapshotVersions == nil {
+		m.Versioning.SnapshotVersions = &MavenSnapshotVersions{}
+	}
+	versions := m.Versioning.SnapshotVersions
+	for i, v := range versions.SnapshotVersion {
+		if v.Classifier == sv.Classifier && v.Extension == sv.Extension {
+			versions.SnapshotVersion[i] = sv
+			return
+		}
+	}
+	versions.SnapshotVersion = append(versions.SnapshotVersion, sv)
+}
+
+//NextBuildNumber returns build number of next snapshot deployment
+func (m *MavenMetadata) NextBuildNumber() int {
+	if m.Versioning.Snapshot == nil {
+		return 1
+	}
+	return m.Versioning.Snapshot.BuildNumber + 1
+}
+
+func (m MavenMetadata) Bytes() ([]byte, error) {
+	data, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
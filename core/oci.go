@@ -0,0 +1,273 @@
+package core
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ociLayoutVersion      = "1.0.0"
+	ociIndexMediaType     = "application/vnd.oci.image.index.v1+json"
+	ociManifestMediaType  = "application/vnd.oci.image.manifest.v1+json"
+	ociConfigMediaType    = "application/vnd.oci.image.config.v1+json"
+	ociLayerMediaType     = "application/vnd.oci.image.layer.v1.tar"
+	ociRefNameAnnotation  = "org.opencontainers.image.ref.name"
+	dockerArchiveManifest = "manifest.json"
+)
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+type ociIndex struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType"`
+	Manifests     []ociDescriptor `json:"manifests"`
+}
+
+type dockerArchiveEntry struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+//ociTarWriter writes blobs of an OCI image layout into a tarball, each blob is written once
+type ociTarWriter struct {
+	tw    *tar.Writer
+	blobs map[string]struct{}
+}
+
+func (w *ociTarWriter) writeFile(name string, data []byte) error {
+	err := w.tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.tw.Write(data)
+	return err
+}
+
+func (w *ociTarWriter) writeBlob(mediaType string, data []byte) (ociDescriptor, error) {
+	desc := ociDescriptor{
+		MediaType: mediaType,
+		Digest:    fmt.Sprintf("sha256:%x", sha256.Sum256(data)),
+		Size:      int64(len(data)),
+	}
+	if _, ok := w.blobs[desc.Digest]; ok {
+		return desc, nil
+	}
+	w.blobs[desc.Digest] = struct{}{}
+	return desc, w.writeFile(fmt.Sprintf("blobs/sha256/%s", strings.TrimPrefix(desc.Digest, "sha256:")), data)
+}
+
+func (w *ociTarWriter) writeBlobFile(mediaType, file string) (ociDescriptor, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return ociDescriptor{}, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, f)
+	if err != nil {
+		return ociDescriptor{}, err
+	}
+	desc := ociDescriptor{
+		MediaType: mediaType,
+		Digest:    fmt.Sprintf("sha256:%x", hasher.Sum(nil)),
+		Size:      size,
+	}
+	if _, ok := w.blobs[desc.Digest]; ok {
+		return desc, nil
+	}
+	w.blobs[desc.Digest] = struct{}{}
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return ociDescriptor{}, err
+	}
+	err = w.tw.WriteHeader(&tar.Header{
+		Name:     fmt.Sprintf("blobs/sha256/%s", strings.TrimPrefix(desc.Digest, "sha256:")),
+		Mode:     0644,
+		Size:     size,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return ociDescriptor{}, err
+	}
+	_, err = io.Copy(w.tw, f)
+	return desc, err
+}
+
+//extractDockerArchive extracts output of docker save into dir and returns symlinks which are used for shared layers
+func extractDockerArchive(r io.Reader, dir string) (map[string]string, error) {
+	links := make(map[string]string)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return links, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(header.Name)
+		if strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return nil, fmt.Errorf("invalid entry %s in image archive", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			links[name] = path.Join(path.Dir(name), header.Linkname)
+		case tar.TypeReg:
+			dest := filepath.Join(dir, filepath.FromSlash(name))
+			err = os.MkdirAll(filepath.Dir(dest), 0755)
+			if err != nil {
+				return nil, err
+			}
+			f, err := os.Create(dest)
+			if err != nil {
+				return nil, err
+			}
+			_, err = io.Copy(f, tr)
+			_ = f.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+//SaveImageAsOCI exports image into a tarball of OCI image layout, refName is annotated as name of image in index.json
+func (c *DockerClient) SaveImageAsOCI(ctx context.Context, image, refName, dest string) error {
+	r, err := c.Client.ImageSave(ctx, []string{image})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	return writeOCIImage(r, image, refName, dest)
+}
+
+//writeOCIImage converts output of docker save into a tarball of OCI image layout
+func writeOCIImage(r io.Reader, image, refName, dest string) error {
+	tmpDir, err := ioutil.TempDir("", "bpp-image-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	links, err := extractDockerArchive(r, tmpDir)
+	if err != nil {
+		return err
+	}
+	resolve := func(name string) string {
+		name = path.Clean(name)
+		for i := 0; i < len(links); i++ {
+			target, ok := links[name]
+			if !ok {
+				break
+			}
+			name = target
+		}
+		return filepath.Join(tmpDir, filepath.FromSlash(name))
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpDir, dockerArchiveManifest))
+	if err != nil {
+		return fmt.Errorf("read manifest of image %s get error %v", image, err)
+	}
+	var entries []dockerArchiveEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return fmt.Errorf("unmarshal manifest of image %s get error %v", image, err)
+	}
+	if len(entries) != 1 {
+		return fmt.Errorf("expect one image in archive of %s but found %d", image, len(entries))
+	}
+
+	err = os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	w := &ociTarWriter{
+		tw:    tar.NewWriter(f),
+		blobs: make(map[string]struct{}),
+	}
+
+	manifest := ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		Layers:        make([]ociDescriptor, 0),
+	}
+	manifest.Config, err = w.writeBlobFile(ociConfigMediaType, resolve(entries[0].Config))
+	if err != nil {
+		return err
+	}
+	for _, layer := range entries[0].Layers {
+		desc, err := w.writeBlobFile(ociLayerMediaType, resolve(layer))
+		if err != nil {
+			return err
+		}
+		manifest.Layers = append(manifest.Layers, desc)
+	}
+	data, err = json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	desc, err := w.writeBlob(ociManifestMediaType, data)
+	if err != nil {
+		return err
+	}
+	desc.Annotations = map[string]string{
+		ociRefNameAnnotation: refName,
+	}
+	data, err = json.Marshal(ociIndex{
+		SchemaVersion: 2,
+		MediaType:     ociIndexMediaType,
+		Manifests:     []ociDescriptor{desc},
+	})
+	if err != nil {
+		return err
+	}
+	err = w.writeFile("index.json", data)
+	if err != nil {
+		return err
+	}
+	err = w.writeFile("oci-layout", []byte(fmt.Sprintf(`{"imageLayoutVersion":"%s"}`, ociLayoutVersion)))
+	if err != nil {
+		return err
+	}
+	return w.tw.Close()
+}
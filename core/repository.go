@@ -1,3 +1,164 @@
 package core
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/utils"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
 
+const fileScheme = "file://"
+
+var ErrRepositoryFileNotFound = errors.New("file not found in repository")
+
+//RepositoryClient reads and writes files of a repository by path which is relative to address of channel
+type RepositoryClient interface {
+	Address() string
+	Get(ctx context.Context, path string) ([]byte, error)
+	Put(ctx context.Context, path string, data io.Reader, size int64) error
+}
+
+//LocalDirectory returns directory of a file:// address, it is used to publish into local filesystem
+func LocalDirectory(address string) (string, bool) {
+	address = utils.Trim(address)
+	if !strings.HasPrefix(address, fileScheme) {
+		return "", false
+	}
+	return filepath.FromSlash(strings.TrimPrefix(address, fileScheme)), true
+}
+
+func NewRepositoryClient(chn config.Channel) (RepositoryClient, error) {
+	address := utils.Trim(chn.Address)
+	if address == "" {
+		return nil, fmt.Errorf("address of channel is empty")
+	}
+	if dir, ok := LocalDirectory(address); ok {
+		if utils.IsStringEmpty(dir) {
+			return nil, fmt.Errorf("directory of channel %s is empty", address)
+		}
+		return &fileRepositoryClient{dir: dir}, nil
+	}
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		return nil, fmt.Errorf("can not recognize scheme of channel %s", address)
+	}
+	username, err := config.ResolveSecret(chn.Username)
+	if err != nil {
+		return nil, err
+	}
+	password, err := config.ResolveSecret(chn.Password)
+	if err != nil {
+		return nil, err
+	}
+	return &httpRepositoryClient{
+		address:  strings.TrimSuffix(address, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{},
+	}, nil
+}
+
+type httpRepositoryClient struct {
+	address  string
+	username string
+	password string
+	client   *http.Client
+}
+
+func (c *httpRepositoryClient) Address() string {
+	return c.address
+}
+
+func (c *httpRepositoryClient) url(path string) string {
+	return fmt.Sprintf("%s/%s", c.address, strings.TrimPrefix(path, "/"))
+}
+
+func (c *httpRepositoryClient) Get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrRepositoryFileNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", c.url(path), res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+func (c *httpRepositoryClient) Put(ctx context.Context, path string, data io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.url(path), data)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	}
+	return fmt.Errorf("put %s: %s", c.url(path), res.Status)
+}
+
+type fileRepositoryClient struct {
+	dir string
+}
+
+func (c *fileRepositoryClient) Address() string {
+	return fileScheme + filepath.ToSlash(c.dir)
+}
+
+func (c *fileRepositoryClient) Get(ctx context.Context, path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(c.dir, filepath.FromSlash(path)))
+	if os.IsNotExist(err) {
+		return nil, ErrRepositoryFileNotFound
+	}
+	return data, err
+}
+
+func (c *fileRepositoryClient) Put(ctx context.Context, path string, data io.Reader, size int64) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	dest := filepath.Join(c.dir, filepath.FromSlash(path))
+	err := os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = io.Copy(f, data)
+	return err
+}
@@ -2,56 +2,216 @@ package core
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
+//Version follows Semantic Versioning 2.0.0 (https://semver.org), e.g. 1.4.0-rc.2+build.57
 type Version struct {
-	Major int
-	Minor int
-	Patch int
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	Build      []string
+}
+
+var (
+	semverIdentifier = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+	semverNumeric    = regexp.MustCompile(`^[0-9]+$`)
+)
+
+func parseNumber(s, name string) (int, error) {
+	if !semverNumeric.MatchString(s) {
+		return 0, fmt.Errorf("%s version %s is not a number", name, s)
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("%s version %s must not contain leading zeroes", name, s)
+	}
+	return strconv.Atoi(s)
+}
+
+func parseIdentifiers(s, name string, numericNoLeadingZero bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+	for _, id := range ids {
+		if !semverIdentifier.MatchString(id) {
+			return nil, fmt.Errorf("%s identifier '%s' is malformed", name, id)
+		}
+		if numericNoLeadingZero && semverNumeric.MatchString(id) && len(id) > 1 && id[0] == '0' {
+			return nil, fmt.Errorf("%s identifier %s must not contain leading zeroes", name, id)
+		}
+	}
+	return ids, nil
 }
 
 func Parse(s string) (v Version, err error) {
-	parts := strings.Split(strings.TrimSpace(s), ".")
+	str := strings.TrimSpace(s)
+	if i := strings.Index(str, "+"); i >= 0 {
+		v.Build, err = parseIdentifiers(str[i+1:], "build metadata", false)
+		if err != nil {
+			err = fmt.Errorf("can not parse string %s to version: %v", s, err)
+			return
+		}
+		str = str[:i]
+	}
+	if i := strings.Index(str, "-"); i >= 0 {
+		v.Prerelease, err = parseIdentifiers(str[i+1:], "pre-release", true)
+		if err != nil {
+			err = fmt.Errorf("can not parse string %s to version: %v", s, err)
+			return
+		}
+		str = str[:i]
+	}
+	parts := strings.Split(str, ".")
 	if len(parts) != 3 {
 		err = fmt.Errorf("can not parse string %s to version", s)
 		return
 	}
-	v.Major, err = strconv.Atoi(parts[0])
+	v.Major, err = parseNumber(parts[0], "major")
 	if err != nil {
 		return
 	}
-	v.Minor, err = strconv.Atoi(parts[1])
+	v.Minor, err = parseNumber(parts[1], "minor")
 	if err != nil {
 		return
 	}
-	v.Patch, err = strconv.Atoi(parts[2])
+	v.Patch, err = parseNumber(parts[2], "patch")
 	if err != nil {
 		return
 	}
 	return
 }
 
+//IsPrerelease returns true if version has pre-release identifiers
+func (v *Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+//NextPatch increases patch version, a pre-release of patch is released as is (1.0.1-rc.1 becomes 1.0.1)
 func (v *Version) NextPatch() {
-	v.Patch = v.Patch + 1
+	if !v.IsPrerelease() {
+		v.Patch = v.Patch + 1
+	}
+	v.Prerelease = nil
+	v.Build = nil
 }
 
+//NextMinor increases minor version, a pre-release of minor is released as is (1.1.0-rc.1 becomes 1.1.0)
 func (v *Version) NextMinor() {
+	if !v.IsPrerelease() || v.Patch != 0 {
+		v.Minor = v.Minor + 1
+	}
 	v.Patch = 0
-	v.Minor = v.Minor + 1
+	v.Prerelease = nil
+	v.Build = nil
 }
 
+//NextMajor increases major version, a pre-release of major is released as is (2.0.0-rc.1 becomes 2.0.0)
 func (v *Version) NextMajor() {
+	if !v.IsPrerelease() || v.Minor != 0 || v.Patch != 0 {
+		v.Major = v.Major + 1
+	}
 	v.Patch = 0
 	v.Minor = 0
-	v.Major = v.Major + 1
+	v.Prerelease = nil
+	v.Build = nil
 }
 
-func (v *Version) String() string {
+//NextPrerelease increases pre-release of version with given identifier, build metadata is removed.
+//	1.0.0 -> 1.0.1-rc.1
+//	1.0.1-rc.1 -> 1.0.1-rc.2
+//	1.0.1-beta.3 -> 1.0.1-rc.1
+//If id is empty, then the existing identifier is increased
+func (v *Version) NextPrerelease(id string) {
+	v.Build = nil
+	if !v.IsPrerelease() {
+		v.Patch = v.Patch + 1
+		if id == "" {
+			v.Prerelease = []string{"1"}
+			return
+		}
+		v.Prerelease = []string{id, "1"}
+		return
+	}
+	if id != "" && v.Prerelease[0] != id {
+		v.Prerelease = []string{id, "1"}
+		return
+	}
+	last := len(v.Prerelease) - 1
+	if n, err := strconv.Atoi(v.Prerelease[last]); err == nil && semverNumeric.MatchString(v.Prerelease[last]) {
+		v.Prerelease[last] = strconv.Itoa(n + 1)
+		return
+	}
+	v.Prerelease = append(v.Prerelease, "1")
+}
+
+//Compare returns -1, 0 or 1 if v has lower, equal or higher precedence than o. Build metadata is ignored
+func (v *Version) Compare(o Version) int {
+	if c := compareInt(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	//a pre-release version has lower precedence than a normal version
+	if len(v.Prerelease) == 0 || len(o.Prerelease) == 0 {
+		return -compareInt(len(v.Prerelease), len(o.Prerelease))
+	}
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(v.Prerelease), len(o.Prerelease))
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+//compareIdentifier compares numeric identifiers numerically and others lexically, numeric one has lower precedence
+func compareIdentifier(a, b string) int {
+	aNum := semverNumeric.MatchString(a)
+	bNum := semverNumeric.MatchString(b)
+	switch {
+	case aNum && bNum:
+		if len(a) != len(b) {
+			return compareInt(len(a), len(b))
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+//Core returns MAJOR.MINOR.PATCH without pre-release and build metadata
+func (v *Version) Core() string {
 	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
 }
 
+func (v *Version) String() string {
+	s := v.Core()
+	if len(v.Prerelease) > 0 {
+		s = fmt.Sprintf("%s-%s", s, strings.Join(v.Prerelease, "."))
+	}
+	if len(v.Build) > 0 {
+		s = fmt.Sprintf("%s+%s", s, strings.Join(v.Build, "."))
+	}
+	return s
+}
+
 func (v *Version) MinorBranch() string {
 	return fmt.Sprintf("%d.%d.x", v.Major, v.Minor)
 }
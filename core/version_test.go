@@ -0,0 +1,189 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   bool
+	}{
+		{input: "1.2.3", want: "1.2.3"},
+		{input: " 1.2.3 ", want: "1.2.3"},
+		{input: "0.0.0", want: "0.0.0"},
+		{input: "1.0.0-alpha", want: "1.0.0-alpha"},
+		{input: "1.0.0-alpha.1", want: "1.0.0-alpha.1"},
+		{input: "1.0.0-0.3.7", want: "1.0.0-0.3.7"},
+		{input: "1.0.0-x.7.z.92", want: "1.0.0-x.7.z.92"},
+		{input: "1.0.0-x-y-z.--", want: "1.0.0-x-y-z.--"},
+		{input: "1.0.0-alpha+001", want: "1.0.0-alpha+001"},
+		{input: "1.0.0+20130313144700", want: "1.0.0+20130313144700"},
+		{input: "1.0.0-beta+exp.sha.5114f85", want: "1.0.0-beta+exp.sha.5114f85"},
+		{input: "1.0.0+21AF26D3---117B344092BD", want: "1.0.0+21AF26D3---117B344092BD"},
+		{input: "1.2", err: true},
+		{input: "1.2.3.4", err: true},
+		{input: "a.b.c", err: true},
+		{input: "01.2.3", err: true},
+		{input: "1.02.3", err: true},
+		{input: "1.2.03", err: true},
+		{input: "-1.2.3", err: true},
+		{input: "1.2.3-", err: true},
+		{input: "1.2.3-01", err: true},
+		{input: "1.2.3-alpha..1", err: true},
+		{input: "1.2.3-alpha_1", err: true},
+		{input: "1.2.3+", err: true},
+		{input: "1.2.3+build..1", err: true},
+		{input: "", err: true},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Parse(%q) = %s, want error", tt.input, v.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) get error %v", tt.input, err)
+			continue
+		}
+		if got := v.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseLeadingZeroOfBuildMetadata(t *testing.T) {
+	v, err := Parse("1.0.0+007")
+	if err != nil {
+		t.Fatalf("build metadata may contain leading zeroes, get error %v", err)
+	}
+	if len(v.Build) != 1 || v.Build[0] != "007" {
+		t.Errorf("build metadata = %v, want [007]", v.Build)
+	}
+}
+
+func mustParse(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q) get error %v", s, err)
+	}
+	return v
+}
+
+//TestComparePrecedence checks example of SemVer 11.4:
+//1.0.0-alpha < 1.0.0-alpha.1 < 1.0.0-alpha.beta < 1.0.0-beta < 1.0.0-beta.2 < 1.0.0-beta.11 < 1.0.0-rc.1 < 1.0.0
+func TestComparePrecedence(t *testing.T) {
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+		"2.1.0",
+		"2.1.1",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a := mustParse(t, ordered[i])
+			b := mustParse(t, ordered[j])
+			want := compareInt(i, j)
+			if got := a.Compare(b); got != want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		//build metadata is ignored
+		{a: "1.0.0+build.1", b: "1.0.0+build.2", want: 0},
+		{a: "1.0.0-rc.1+a", b: "1.0.0-rc.1", want: 0},
+		//numeric identifiers are compared numerically
+		{a: "1.0.0-2", b: "1.0.0-10", want: -1},
+		{a: "1.0.0-rc.10", b: "1.0.0-rc.9", want: 1},
+		//numeric identifier has lower precedence than alphanumeric one
+		{a: "1.0.0-1", b: "1.0.0-a", want: -1},
+		{a: "1.0.0-a", b: "1.0.0-1", want: 1},
+		//alphanumeric identifiers are compared in ASCII order
+		{a: "1.0.0-Beta", b: "1.0.0-alpha", want: -1},
+		{a: "10.0.0", b: "9.0.0", want: 1},
+		{a: "1.10.0", b: "1.9.0", want: 1},
+	}
+	for _, tt := range tests {
+		a := mustParse(t, tt.a)
+		if got := a.Compare(mustParse(t, tt.b)); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		input string
+		next  func(v *Version)
+		name  string
+		want  string
+	}{
+		{input: "1.0.0", next: (*Version).NextPatch, name: "patch", want: "1.0.1"},
+		{input: "1.0.0+build.5", next: (*Version).NextPatch, name: "patch", want: "1.0.1"},
+		{input: "1.0.1-rc.1", next: (*Version).NextPatch, name: "patch", want: "1.0.1"},
+		{input: "1.0.3", next: (*Version).NextMinor, name: "minor", want: "1.1.0"},
+		{input: "1.1.0-rc.1", next: (*Version).NextMinor, name: "minor", want: "1.1.0"},
+		{input: "1.0.1-rc.1", next: (*Version).NextMinor, name: "minor", want: "1.1.0"},
+		{input: "1.2.3", next: (*Version).NextMajor, name: "major", want: "2.0.0"},
+		{input: "2.0.0-rc.1", next: (*Version).NextMajor, name: "major", want: "2.0.0"},
+		{input: "1.1.0-rc.1", next: (*Version).NextMajor, name: "major", want: "2.0.0"},
+	}
+	for _, tt := range tests {
+		v := mustParse(t, tt.input)
+		tt.next(&v)
+		if got := v.String(); got != tt.want {
+			t.Errorf("next %s of %s = %s, want %s", tt.name, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNextPrerelease(t *testing.T) {
+	tests := []struct {
+		input string
+		id    string
+		want  string
+	}{
+		{input: "1.0.0", id: "rc", want: "1.0.1-rc.1"},
+		{input: "1.0.0", id: "", want: "1.0.1-1"},
+		{input: "1.0.0+build.7", id: "rc", want: "1.0.1-rc.1"},
+		{input: "1.0.1-rc.1", id: "rc", want: "1.0.1-rc.2"},
+		{input: "1.0.1-rc.9", id: "rc", want: "1.0.1-rc.10"},
+		{input: "1.0.1-rc.1", id: "", want: "1.0.1-rc.2"},
+		{input: "1.0.1-beta.3", id: "rc", want: "1.0.1-rc.1"},
+		{input: "1.0.1-rc", id: "rc", want: "1.0.1-rc.1"},
+		{input: "1.0.1-1", id: "", want: "1.0.1-2"},
+		{input: "1.0.1-rc.1+build.2", id: "rc", want: "1.0.1-rc.2"},
+	}
+	for _, tt := range tests {
+		v := mustParse(t, tt.input)
+		v.NextPrerelease(tt.id)
+		if got := v.String(); got != tt.want {
+			t.Errorf("NextPrerelease(%q) of %s = %s, want %s", tt.id, tt.input, got, tt.want)
+		}
+		//next pre-release of these versions has higher precedence
+		before := mustParse(t, tt.input)
+		if before.Compare(v) >= 0 {
+			t.Errorf("%s is not higher than %s", v.String(), tt.input)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package instrument
 import (
 	"context"
 	"fmt"
+	"github.com/locngoxuan/buildpack/config"
 	"github.com/locngoxuan/buildpack/core"
 	"path/filepath"
 	"plugin"
@@ -15,6 +16,8 @@ const (
 	FuncBuild                  = "Build"
 	FuncPack                   = "Pack"
 	FuncPublish                = "Publish"
+	FuncPublishPlan            = "PublishPlan"
+	FuncUpdateVersion          = "UpdateVersion"
 )
 
 type BuildRequest struct {
@@ -28,6 +31,8 @@ type BuildFunc func(ctx context.Context, request BuildRequest) Response
 
 var buildDockerImages = make(map[string]string)
 var buildFuns = make(map[string]BuildFunc)
+var buildWithoutDocker = make(map[string]struct{})
+var buildConfigs = make(map[string]func() interface{})
 
 func RegisterBuildDockerImage(builderName, dockerImage string) {
 	buildDockerImages[strings.ToLower(strings.TrimSpace(builderName))] = strings.TrimSpace(dockerImage)
@@ -37,6 +42,36 @@ func RegisterBuildFunction(builderName string, f BuildFunc) {
 	buildFuns[strings.ToLower(strings.TrimSpace(builderName))] = f
 }
 
+//RegisterBuildWithoutDocker marks a builder that runs inside bpp process, then neither docker client nor build image is prepared for it
+func RegisterBuildWithoutDocker(builderName string) {
+	buildWithoutDocker[strings.ToLower(strings.TrimSpace(builderName))] = struct{}{}
+}
+
+//RegisterBuildConfig registers config of builder which is read from build section of module config,
+//then keys that are not known by builder can be detected
+func RegisterBuildConfig(builderName string, newConfig func() interface{}) {
+	buildConfigs[strings.ToLower(strings.TrimSpace(builderName))] = newConfig
+}
+
+//NewBuildConfig returns pointer to config of builder, it is BuildConfig if builder does not register its own config
+func NewBuildConfig(builderName string) interface{} {
+	newConfig, ok := buildConfigs[strings.ToLower(strings.TrimSpace(builderName))]
+	if !ok {
+		return &config.BuildConfig{}
+	}
+	return newConfig()
+}
+
+func IsBuilderRegistered(builderName string) bool {
+	_, ok := buildFuns[strings.ToLower(strings.TrimSpace(builderName))]
+	return ok
+}
+
+func IsDockerRequiredForBuild(builderName string) bool {
+	_, ok := buildWithoutDocker[strings.ToLower(strings.TrimSpace(builderName))]
+	return !ok
+}
+
 func DefaultDockerImageName(moduleAbsPath, builderName string) (string, error) {
 	if strings.HasPrefix(builderName, "external") {
 		pluginName := strings.TrimPrefix(builderName, "external.")
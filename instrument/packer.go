@@ -3,6 +3,7 @@ package instrument
 import (
 	"context"
 	"fmt"
+	"github.com/locngoxuan/buildpack/config"
 	"github.com/locngoxuan/buildpack/core"
 	"path/filepath"
 	"plugin"
@@ -20,6 +21,9 @@ type PackFunc func(ctx context.Context, request PackRequest) Response
 
 var packDockerImages = make(map[string]string)
 var packFuns = make(map[string]PackFunc)
+var packWithoutDocker = make(map[string]struct{})
+var packWithoutImage = make(map[string]struct{})
+var packConfigs = make(map[string]func() interface{})
 
 func RegisterPackDockerImage(builderName, dockerImage string) {
 	packDockerImages[strings.ToLower(strings.TrimSpace(builderName))] = strings.TrimSpace(dockerImage)
@@ -29,6 +33,46 @@ func RegisterPackFunction(builderName string, f PackFunc) {
 	packFuns[strings.ToLower(strings.TrimSpace(builderName))] = f
 }
 
+//RegisterPackConfig registers config of packer which is read from pack section of module config,
+//then keys that are not known by packer can be detected
+func RegisterPackConfig(packType string, newConfig func() interface{}) {
+	packConfigs[strings.ToLower(strings.TrimSpace(packType))] = newConfig
+}
+
+//NewPackConfig returns pointer to config of packer, it is PackConfig if packer does not register its own config
+func NewPackConfig(packType string) interface{} {
+	newConfig, ok := packConfigs[strings.ToLower(strings.TrimSpace(packType))]
+	if !ok {
+		return &config.PackConfig{}
+	}
+	return newConfig()
+}
+
+func IsPackerRegistered(packType string) bool {
+	_, ok := packFuns[strings.ToLower(strings.TrimSpace(packType))]
+	return ok
+}
+
+//RegisterPackWithoutDocker marks a packer that runs inside bpp process, then neither docker client nor pack image is prepared for it
+func RegisterPackWithoutDocker(packType string) {
+	packWithoutDocker[strings.ToLower(strings.TrimSpace(packType))] = struct{}{}
+}
+
+func IsDockerRequiredForPack(packType string) bool {
+	_, ok := packWithoutDocker[strings.ToLower(strings.TrimSpace(packType))]
+	return !ok
+}
+
+//RegisterPackWithoutImage marks a packer that uses docker client directly, then pack image is not prepared for it
+func RegisterPackWithoutImage(packType string) {
+	packWithoutImage[strings.ToLower(strings.TrimSpace(packType))] = struct{}{}
+}
+
+func IsPackImageRequired(packType string) bool {
+	_, ok := packWithoutImage[strings.ToLower(strings.TrimSpace(packType))]
+	return !ok
+}
+
 func DefaultPackDockerImage(moduleAbsPath, packType string) (string, error) {
 	if strings.HasPrefix(packType, "external") {
 		pluginName := strings.TrimPrefix(packType, "external.")
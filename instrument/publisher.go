@@ -2,6 +2,7 @@ package instrument
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/locngoxuan/buildpack/config"
 	"path/filepath"
@@ -12,17 +13,67 @@ import (
 type PublishRequest struct {
 	BaseProperties
 	config.PublishConfig
-	Repositories map[string]config.Repository
+	Repositories     map[string]config.Repository
+	DockerHosts      []string
+	DockerRegistries []config.DockerRegistry
+	//Lenient allows publisher skipping expected artifacts which do not exist
+	Lenient bool
+}
+
+//PublishItem describes where a source is going to be published to
+type PublishItem struct {
+	RepoId      string `json:"repo_id"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
 }
 
 type PublishFunc func(ctx context.Context, request PublishRequest) Response
 
+//PublishPlanFunc returns items which would be published by request without publishing anything
+type PublishPlanFunc func(ctx context.Context, request PublishRequest) ([]PublishItem, error)
+
+var ErrPublishPlanNotSupported = errors.New("publish plan is not supported")
+
 var publishFuncs = make(map[string]PublishFunc)
+var publishPlanFuncs = make(map[string]PublishPlanFunc)
 
 func RegisterPublishFunction(builderName string, f PublishFunc) {
 	publishFuncs[strings.ToLower(strings.TrimSpace(builderName))] = f
 }
 
+func RegisterPublishPlanFunction(builderName string, f PublishPlanFunc) {
+	publishPlanFuncs[strings.ToLower(strings.TrimSpace(builderName))] = f
+}
+
+func IsPublisherRegistered(publishType string) bool {
+	_, ok := publishFuncs[strings.ToLower(strings.TrimSpace(publishType))]
+	return ok
+}
+
+func PlanPublish(ctx context.Context, request PublishRequest) ([]PublishItem, error) {
+	if strings.HasPrefix(request.Type, "external") {
+		pluginName := strings.TrimPrefix(request.Type, "external.")
+		pluginPath := filepath.Join(request.WorkDir, request.ModulePath, fmt.Sprintf("%s%s", pluginName, extension))
+		p, err := plugin.Open(pluginPath)
+		if err != nil {
+			return nil, err
+		}
+		f, err := p.Lookup(FuncPublishPlan)
+		if err != nil {
+			return nil, ErrPublishPlanNotSupported
+		}
+		return f.(func(context.Context, PublishRequest) ([]PublishItem, error))(ctx, request)
+	}
+	if _, ok := publishFuncs[strings.ToLower(strings.TrimSpace(request.Type))]; !ok {
+		return nil, fmt.Errorf("can not recognize publish type")
+	}
+	f, ok := publishPlanFuncs[strings.ToLower(strings.TrimSpace(request.Type))]
+	if !ok || f == nil {
+		return nil, ErrPublishPlanNotSupported
+	}
+	return f(ctx, request)
+}
+
 func PublishPackage(ctx context.Context, request PublishRequest) Response {
 	if strings.HasPrefix(request.Type, "external") {
 		pluginName := strings.TrimPrefix(request.Type, "external.")
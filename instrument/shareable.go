@@ -1,9 +1,12 @@
 package instrument
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 type BaseProperties struct {
@@ -17,10 +20,37 @@ type BaseProperties struct {
 	ModuleOutputs []string
 	LocalBuild    bool
 	BuildNumber   int
+	//DevVersion is version of dev build, it is rendered from dev version template of project
+	DevVersion string
+}
+
+//DefaultDevLabel is appended to version of dev build if neither label nor dev version template is configured
+const DefaultDevLabel = "SNAPSHOT"
+
+//BuildVersion returns version which artifacts are built, packed and published with
+func (p BaseProperties) BuildVersion() string {
+	if !p.DevMode {
+		return p.Version
+	}
+	if p.DevVersion == "" {
+		return fmt.Sprintf("%s-%s", p.Version, DefaultDevLabel)
+	}
+	return p.DevVersion
 }
 
 var extension string = ""
 
+//IsExternal returns true if type is implemented by a plugin, e.g. external.custom
+func IsExternal(typeName string) bool {
+	return strings.HasPrefix(typeName, "external")
+}
+
+//PluginFile returns file of plugin which implements external type in module directory
+func PluginFile(moduleAbsPath, typeName string) string {
+	pluginName := strings.TrimPrefix(typeName, "external.")
+	return filepath.Join(moduleAbsPath, fmt.Sprintf("%s%s", pluginName, extension))
+}
+
 func init() {
 	//detect os runtime
 	if runtime.GOOS == "linux" {
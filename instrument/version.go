@@ -0,0 +1,79 @@
+package instrument
+
+import (
+	"fmt"
+	"github.com/locngoxuan/buildpack/config"
+	"path/filepath"
+	"plugin"
+	"strings"
+)
+
+//VersionUpdateRequest is a version file of module whose content is read from repository being pumped
+type VersionUpdateRequest struct {
+	config.VersionFile
+	WorkDir    string
+	ModuleName string
+	ModulePath string
+	Version    string
+	Content    []byte
+}
+
+//VersionUpdateFunc returns content of version file in which version is replaced by the requested one
+type VersionUpdateFunc func(request VersionUpdateRequest) ([]byte, error)
+
+var versionUpdateFuncs = make(map[string]VersionUpdateFunc)
+var versionFileNames = make(map[string]string)
+
+func RegisterVersionUpdateFunction(updaterName string, f VersionUpdateFunc) {
+	versionUpdateFuncs[strings.ToLower(strings.TrimSpace(updaterName))] = f
+}
+
+func IsVersionUpdaterRegistered(updaterName string) bool {
+	_, ok := versionUpdateFuncs[strings.ToLower(strings.TrimSpace(updaterName))]
+	return ok
+}
+
+//RegisterVersionFileName registers file which is updated if version file of updater does not specify one
+func RegisterVersionFileName(updaterName, file string) {
+	versionFileNames[strings.ToLower(strings.TrimSpace(updaterName))] = file
+}
+
+//VersionFileName returns file of version file which is relative to module
+func VersionFileName(file config.VersionFile) (string, error) {
+	if strings.TrimSpace(file.File) != "" {
+		return strings.TrimSpace(file.File), nil
+	}
+	name, ok := versionFileNames[strings.ToLower(strings.TrimSpace(file.Type))]
+	if !ok || name == "" {
+		return "", fmt.Errorf("file of version file %s is not specified", file.Type)
+	}
+	return name, nil
+}
+
+func UpdateVersion(request VersionUpdateRequest) ([]byte, error) {
+	if strings.HasPrefix(request.Type, "external") {
+		pluginName := strings.TrimPrefix(request.Type, "external.")
+		pluginPath := filepath.Join(request.WorkDir, request.ModulePath, fmt.Sprintf("%s%s", pluginName, extension))
+		p, err := plugin.Open(pluginPath)
+		if err != nil {
+			return nil, err
+		}
+		f, err := p.Lookup(FuncUpdateVersion)
+		if err != nil {
+			return nil, err
+		}
+		fn, ok := f.(func(VersionUpdateRequest) ([]byte, error))
+		if !ok {
+			return nil, fmt.Errorf("can not invoke function UpdateVersion in plugin %s", request.Type)
+		}
+		return fn(request)
+	}
+	f, ok := versionUpdateFuncs[strings.ToLower(strings.TrimSpace(request.Type))]
+	if !ok {
+		return nil, fmt.Errorf("can not recognize version file type %s", request.Type)
+	}
+	if f == nil {
+		return nil, fmt.Errorf("version update function is nil")
+	}
+	return f(request)
+}
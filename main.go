@@ -37,6 +37,12 @@ func Run() {
 		os.Exit(1)
 	}
 
+	//profile can be chosen by environment variable which may be declared in .env files
+	if utils.IsStringEmpty(arg.Profile) {
+		arg.Profile = os.Getenv(config.EnvProfile)
+	}
+	config.SetProfile(arg.Profile)
+
 	workDir, err = filepath.Abs(".")
 	if err != nil {
 		log.Printf("FAILURE: looking working directory get error %v", err)
@@ -65,8 +71,15 @@ func Run() {
 	builtin.InitBuiltInFunction()
 	err = run(ctx)
 	if err != nil {
+		if arg.JsonOutput {
+			_, _ = fmt.Fprintln(os.Stderr, fmt.Sprintf("%s: %s", utils.TextRed("FAILURE"), err))
+			os.Exit(1)
+		}
 		fmt.Println(fmt.Sprintf("%s: %s", utils.TextRed("FAILURE"), err))
 		os.Exit(1)
 	}
-	fmt.Println(utils.TextGreen("SUCCESS"))
+	//json output must be kept parsable
+	if !arg.JsonOutput {
+		fmt.Println(utils.TextGreen("SUCCESS"))
+	}
 }
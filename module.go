@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/locngoxuan/buildpack/config"
+	"github.com/locngoxuan/buildpack/instrument"
 	"github.com/locngoxuan/buildpack/utils"
 	"log"
 	"os"
@@ -13,9 +14,11 @@ import (
 )
 
 type Module struct {
-	Id   int
-	Name string
-	Path string
+	Id        int
+	Name      string
+	Path      string
+	Version   string
+	DependsOn []string
 
 	moduleDir string
 	output    string
@@ -40,9 +43,41 @@ func (m *Module) initiate() error {
 	if err != nil {
 		return err
 	}
+	//version in project config takes precedence over the one in module config
+	if utils.IsStringEmpty(m.Version) {
+		m.Version = utils.Trim(m.config.Version)
+	}
 	return nil
 }
 
+//versionOf returns version of module if it is independently versioned, otherwise version of project
+func (m *Module) versionOf(projectVersion string) string {
+	if utils.IsStringEmpty(m.Version) {
+		return projectVersion
+	}
+	return m.Version
+}
+
+//devVersion renders dev version template of project for module. Build number, git sha and timestamp are taken from build info
+//then build, pack and publish use the same version
+func (m *Module) devVersion(projectVersion string, info config.BuildOutputInfo) (string, error) {
+	if info.Release {
+		return "", nil
+	}
+	label := utils.Trim(m.config.Label)
+	if utils.IsStringEmpty(label) {
+		label = instrument.DefaultDevLabel
+	}
+	return renderText("dev version", cfg.DevVersion, DefaultDevVersionTemplate, DevVersionTemplate{
+		Version:     m.versionOf(projectVersion),
+		Label:       label,
+		Module:      m.Name,
+		BuildNumber: info.BuildNumber,
+		Timestamp:   info.Timestamp,
+		gitSha:      info.GitSha,
+	})
+}
+
 func (m *Module) clean(ctx context.Context) error {
 	outputDir := filepath.Join(outputDir, m.Name)
 	_, err := os.Stat(outputDir)
@@ -64,12 +99,14 @@ func (m *Module) publish(ctx context.Context) error {
 	return nil
 }
 
-func initModule(id int, name, path string) (Module, error) {
-	log.Printf("initiating module %s at %s", name, path)
+func initModule(info config.ModuleInfo) (Module, error) {
+	log.Printf("initiating module %s at %s", info.Name, info.Path)
 	m := Module{
-		Id:   id,
-		Name: name,
-		Path: path,
+		Id:        info.Id,
+		Name:      info.Name,
+		Path:      info.Path,
+		Version:   utils.Trim(info.Version),
+		DependsOn: info.DependsOn,
 	}
 	err := m.initiate()
 	if err != nil {
@@ -84,7 +121,7 @@ func prepareListModule() ([]Module, error) {
 	ms := make([]Module, 0)
 	if utils.IsStringEmpty(arg.Module) {
 		for _, module := range cfg.Modules {
-			m, err := initModule(module.Id, module.Name, module.Path)
+			m, err := initModule(module)
 			if err != nil {
 				return nil, err
 			}
@@ -109,7 +146,7 @@ func prepareListModule() ([]Module, error) {
 			if (excludes && ok) || (!excludes && !ok) {
 				continue
 			}
-			m, err := initModule(module.Id, module.Name, module.Path)
+			m, err := initModule(module)
 			if err != nil {
 				return nil, err
 			}
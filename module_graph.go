@@ -0,0 +1,215 @@
+package buildpack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/locngoxuan/buildpack/config"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//ModuleGraph is the dependency graph of modules that take part in a run.
+//An edge from A to B means that A depends on B, then B must be completed before A is started
+type ModuleGraph struct {
+	modules []Module
+	edges   map[string][]string
+}
+
+type moduleFunc func(ctx context.Context, m Module) error
+
+func newModuleGraph(ms []Module) (*ModuleGraph, error) {
+	g := &ModuleGraph{
+		modules: make([]Module, len(ms)),
+		edges:   make(map[string][]string),
+	}
+	copy(g.modules, ms)
+	sort.SliceStable(g.modules, func(i, j int) bool {
+		return g.modules[i].Id < g.modules[j].Id
+	})
+
+	selected := make(map[string]struct{})
+	for _, m := range g.modules {
+		if _, ok := selected[m.Name]; ok {
+			return nil, fmt.Errorf("module %s is declared more than once", m.Name)
+		}
+		selected[m.Name] = struct{}{}
+	}
+	known := make(map[string]config.ModuleInfo)
+	for _, m := range cfg.Modules {
+		known[m.Name] = m
+	}
+
+	explicit := false
+	for _, m := range g.modules {
+		if len(m.DependsOn) > 0 {
+			explicit = true
+			break
+		}
+	}
+
+	for _, m := range g.modules {
+		if !explicit {
+			//no module declares depends_on, then keep the old behaviour:
+			//a module waits for every module having lower id
+			for _, other := range g.modules {
+				if other.Id < m.Id {
+					g.edges[m.Name] = append(g.edges[m.Name], other.Name)
+				}
+			}
+			continue
+		}
+		deps, err := resolveDependencies(m.Name, m.DependsOn, known, selected)
+		if err != nil {
+			return nil, err
+		}
+		g.edges[m.Name] = deps
+	}
+
+	err := g.detectCycle()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range g.modules {
+		if len(g.edges[m.Name]) > 0 {
+			log.Printf("[%s] waits for %s", m.Name, strings.Join(g.edges[m.Name], ", "))
+		}
+	}
+	return g, nil
+}
+
+//resolveDependencies returns dependencies of a module which take part in the graph.
+//A dependency which is not part of the graph (not selected by --module or not configured for the current stage)
+//is replaced by its own dependencies, then ordering across it is still kept.
+//For example, if yarn module B depends on mvn module A and mvn module C depends on B,
+//then in pack stage where B has no packer, C still waits for A
+func resolveDependencies(name string, dependsOn []string, known map[string]config.ModuleInfo, selected map[string]struct{}) ([]string, error) {
+	deps := make([]string, 0)
+	added := make(map[string]struct{})
+	visited := make(map[string]struct{})
+	var walk func(owner string, names []string) error
+	walk = func(owner string, names []string) error {
+		for _, dep := range names {
+			dep = strings.TrimSpace(dep)
+			info, ok := known[dep]
+			if !ok {
+				return fmt.Errorf("module %s depends on unknown module %s", owner, dep)
+			}
+			if _, ok := selected[dep]; ok {
+				if _, ok := added[dep]; !ok {
+					added[dep] = struct{}{}
+					deps = append(deps, dep)
+				}
+				continue
+			}
+			if _, ok := visited[dep]; ok {
+				continue
+			}
+			visited[dep] = struct{}{}
+			err := walk(dep, info.DependsOn)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := walk(name, dependsOn)
+	if err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+func (g *ModuleGraph) detectCycle() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	path := make([]string, 0)
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			i := len(path) - 1
+			for i > 0 && path[i] != name {
+				i--
+			}
+			cycle := append(append([]string{}, path[i:]...), name)
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range g.edges[name] {
+			err := visit(dep)
+			if err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, m := range g.modules {
+		err := visit(m.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//run executes fn for every module in the graph. A module is started as soon as all of its dependencies are completed,
+//then modules which do not depend on each other are run in parallel.
+//If any module fails, the others which are not started yet are aborted
+func (g *ModuleGraph) run(ctx context.Context, fn moduleFunc) error {
+	newContext, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(map[string]chan struct{})
+	for _, m := range g.modules {
+		done[m.Name] = make(chan struct{})
+	}
+
+	var mutex sync.Mutex
+	var errOut bytes.Buffer
+	defer errOut.Reset()
+
+	wg := new(sync.WaitGroup)
+	for _, module := range g.modules {
+		wg.Add(1)
+		go func(c context.Context, m Module) {
+			defer wg.Done()
+			defer close(done[m.Name])
+			for _, dep := range g.edges[m.Name] {
+				select {
+				case <-done[dep]:
+				case <-c.Done():
+				}
+			}
+			if c.Err() != nil {
+				log.Printf("[%s] is aborted", m.Name)
+				return
+			}
+			e := fn(c, m)
+			if e != nil {
+				mutex.Lock()
+				errOut.WriteString(fmt.Sprintf("[%s] is failure: %s\n", m.Name, e.Error()))
+				mutex.Unlock()
+				cancel()
+			}
+		}(newContext, module)
+	}
+	wg.Wait()
+
+	if errOut.Len() > 0 {
+		return errors.New(errOut.String())
+	}
+	return ctx.Err()
+}
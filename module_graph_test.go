@@ -0,0 +1,270 @@
+package buildpack
+
+import (
+	"context"
+	"errors"
+	"github.com/locngoxuan/buildpack/config"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//setModules sets modules of project config and returns modules which are selected by name
+func setModules(t *testing.T, infos []config.ModuleInfo, names ...string) []Module {
+	t.Helper()
+	old := cfg
+	t.Cleanup(func() {
+		cfg = old
+	})
+	cfg = config.ProjectConfig{Modules: infos}
+	ms := make([]Module, 0)
+	for _, name := range names {
+		for _, info := range infos {
+			if info.Name == name {
+				ms = append(ms, Module{Id: info.Id, Name: info.Name, DependsOn: info.DependsOn})
+			}
+		}
+	}
+	return ms
+}
+
+func TestNewModuleGraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		infos    []config.ModuleInfo
+		selected []string
+		want     map[string][]string
+		err      string
+	}{
+		{
+			name: "ordered by id without depends_on",
+			infos: []config.ModuleInfo{
+				{Id: 2, Name: "b"},
+				{Id: 1, Name: "a"},
+				{Id: 3, Name: "c"},
+			},
+			selected: []string{"c", "a", "b"},
+			want: map[string][]string{
+				"b": {"a"},
+				"c": {"a", "b"},
+			},
+		},
+		{
+			name: "explicit depends_on",
+			infos: []config.ModuleInfo{
+				{Id: 1, Name: "a"},
+				{Id: 2, Name: "b"},
+				{Id: 3, Name: "c", DependsOn: []string{"a"}},
+			},
+			selected: []string{"a", "b", "c"},
+			want: map[string][]string{
+				"a": {},
+				"b": {},
+				"c": {"a"},
+			},
+		},
+		{
+			name: "walk through unselected module",
+			infos: []config.ModuleInfo{
+				{Id: 1, Name: "a"},
+				{Id: 2, Name: "b", DependsOn: []string{"a"}},
+				{Id: 3, Name: "c", DependsOn: []string{"b"}},
+			},
+			selected: []string{"a", "c"},
+			want: map[string][]string{
+				"a": {},
+				"c": {"a"},
+			},
+		},
+		{
+			name: "walk through chain of unselected modules",
+			infos: []config.ModuleInfo{
+				{Id: 1, Name: "a"},
+				{Id: 2, Name: "b", DependsOn: []string{"a"}},
+				{Id: 3, Name: "c", DependsOn: []string{"b", "a"}},
+				{Id: 4, Name: "d", DependsOn: []string{"c", " b "}},
+			},
+			selected: []string{"a", "d"},
+			want: map[string][]string{
+				"a": {},
+				"d": {"a"},
+			},
+		},
+		{
+			name: "unknown dependency",
+			infos: []config.ModuleInfo{
+				{Id: 1, Name: "a", DependsOn: []string{"x"}},
+			},
+			selected: []string{"a"},
+			err:      "module a depends on unknown module x",
+		},
+		{
+			name: "unknown dependency of unselected module",
+			infos: []config.ModuleInfo{
+				{Id: 1, Name: "a", DependsOn: []string{"b"}},
+				{Id: 2, Name: "b", DependsOn: []string{"x"}},
+			},
+			selected: []string{"a"},
+			err:      "module b depends on unknown module x",
+		},
+		{
+			name: "self dependency",
+			infos: []config.ModuleInfo{
+				{Id: 1, Name: "a", DependsOn: []string{"a"}},
+			},
+			selected: []string{"a"},
+			err:      "dependency cycle detected: a -> a",
+		},
+		{
+			name: "cycle",
+			infos: []config.ModuleInfo{
+				{Id: 1, Name: "a", DependsOn: []string{"c"}},
+				{Id: 2, Name: "b", DependsOn: []string{"a"}},
+				{Id: 3, Name: "c", DependsOn: []string{"b"}},
+			},
+			selected: []string{"a", "b", "c"},
+			err:      "dependency cycle detected: a -> c -> b -> a",
+		},
+		{
+			name: "cycle through unselected module",
+			infos: []config.ModuleInfo{
+				{Id: 1, Name: "a", DependsOn: []string{"b"}},
+				{Id: 2, Name: "b", DependsOn: []string{"c"}},
+				{Id: 3, Name: "c", DependsOn: []string{"a"}},
+			},
+			selected: []string{"a", "c"},
+			err:      "dependency cycle detected: a -> c -> a",
+		},
+		{
+			name: "duplicated module",
+			infos: []config.ModuleInfo{
+				{Id: 1, Name: "a"},
+			},
+			selected: []string{"a", "a"},
+			err:      "module a is declared more than once",
+		},
+	}
+	for _, tt := range tests {
+		ms := setModules(t, tt.infos, tt.selected...)
+		g, err := newModuleGraph(ms)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: error = %v, want %s", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: newModuleGraph get error %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(g.edges, tt.want) {
+			t.Errorf("%s: edges = %v, want %v", tt.name, g.edges, tt.want)
+		}
+	}
+}
+
+func TestModuleGraphRunOrder(t *testing.T) {
+	ms := setModules(t, []config.ModuleInfo{
+		{Id: 1, Name: "a"},
+		{Id: 2, Name: "b", DependsOn: []string{"a"}},
+		{Id: 3, Name: "c", DependsOn: []string{"a"}},
+		{Id: 4, Name: "d", DependsOn: []string{"b", "c"}},
+	}, "a", "b", "c", "d")
+	g, err := newModuleGraph(ms)
+	if err != nil {
+		t.Fatalf("newModuleGraph get error %v", err)
+	}
+	var mutex sync.Mutex
+	completed := make(map[string]struct{})
+	err = g.run(context.Background(), func(ctx context.Context, m Module) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, dep := range g.edges[m.Name] {
+			if _, ok := completed[dep]; !ok {
+				t.Errorf("%s is started before %s is completed", m.Name, dep)
+			}
+		}
+		completed[m.Name] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("run get error %v", err)
+	}
+	if len(completed) != len(ms) {
+		t.Errorf("%d modules are completed, want %d", len(completed), len(ms))
+	}
+}
+
+func TestModuleGraphRunCancelsOnError(t *testing.T) {
+	ms := setModules(t, []config.ModuleInfo{
+		{Id: 1, Name: "a"},
+		{Id: 2, Name: "b", DependsOn: []string{"a"}},
+		{Id: 3, Name: "c", DependsOn: []string{"b"}},
+		{Id: 4, Name: "slow"},
+	}, "a", "b", "c", "slow")
+	g, err := newModuleGraph(ms)
+	if err != nil {
+		t.Fatalf("newModuleGraph get error %v", err)
+	}
+	var mutex sync.Mutex
+	started := make(map[string]struct{})
+	slowStarted := make(chan struct{})
+	slowCancelled := false
+	err = g.run(context.Background(), func(ctx context.Context, m Module) error {
+		mutex.Lock()
+		started[m.Name] = struct{}{}
+		mutex.Unlock()
+		switch m.Name {
+		case "a":
+			//fail only when slow is running, then its context must be cancelled
+			<-slowStarted
+			return errors.New("compile error")
+		case "slow":
+			close(slowStarted)
+			select {
+			case <-ctx.Done():
+				mutex.Lock()
+				slowCancelled = true
+				mutex.Unlock()
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("run returns no error, want error of module a")
+	}
+	if !strings.Contains(err.Error(), "[a] is failure: compile error") {
+		t.Errorf("error = %v, want error of module a", err)
+	}
+	for _, name := range []string{"b", "c"} {
+		if _, ok := started[name]; ok {
+			t.Errorf("%s is started after its dependency failed", name)
+		}
+	}
+	if !slowCancelled {
+		t.Error("context of running module is not cancelled")
+	}
+}
+
+func TestModuleGraphRunParentContext(t *testing.T) {
+	ms := setModules(t, []config.ModuleInfo{
+		{Id: 1, Name: "a"},
+	}, "a")
+	g, err := newModuleGraph(ms)
+	if err != nil {
+		t.Fatalf("newModuleGraph get error %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = g.run(ctx, func(ctx context.Context, m Module) error {
+		t.Errorf("%s is started with cancelled context", m.Name)
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -3,7 +3,10 @@ package buildpack
 import (
 	"bytes"
 	"fmt"
+	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/utils"
 	"os"
+	"path"
 	"path/filepath"
 	"text/template"
 )
@@ -19,12 +22,67 @@ WORKDIR /working
 
 	ErrorDetail = `{{.Error}}
 {{.Detail}}`
+
+	DefaultChangelogTemplate = `## {{.Version}} ({{.Date}})
+{{range .Groups}}
+### {{.Title}}
+{{range .Modules}}{{if .Name}}
+#### {{.Name}}
+{{end}}
+{{range .Entries}}- {{if .Scope}}**{{.Scope}}:** {{end}}{{.Description}} ({{.ShortHash}})
+{{end}}{{end}}{{end}}`
+
+	DefaultTagNameTemplate       = `{{if .Module}}{{.Module}}@{{end}}{{.Version}}`
+	DefaultTagMessageTemplate    = `{{if .Module}}{{.Module}} {{.Version}}{{else}}v{{.Version}}{{end}}`
+	DefaultCommitMessageTemplate = `increasing version {{if .Module}}of {{.Module}} {{end}}to {{.Version}}`
+	DefaultDevVersionTemplate    = `{{.Version}}-{{.Label}}`
+
+	//DevVersionTimestampLayout is layout of timestamp of dev version, as timestamp of maven snapshot
+	DevVersionTimestampLayout = "20060102.150405"
 )
 
 type BuilderTemplate struct {
 	Image string
 }
 
+//GitTemplate is data of templates of tag name, tag message and commit message that are configured in git config
+type GitTemplate struct {
+	Version         string
+	PreviousVersion string
+	//Module is empty if whole project is pumped
+	Module string
+}
+
+//DevVersionTemplate is data of dev version template that is configured in project
+type DevVersionTemplate struct {
+	Version     string
+	Label       string
+	Module      string
+	BuildNumber int
+	//Timestamp is UTC time when build starts, e.g. 20201231.235959
+	Timestamp string
+	gitSha    string
+}
+
+//GitSha is hash of commit which is built, template can not be rendered if working directory is not a git repository
+func (t DevVersionTemplate) GitSha() (string, error) {
+	if utils.IsStringEmpty(t.gitSha) {
+		return "", fmt.Errorf("git sha of build is unknown")
+	}
+	return t.gitSha, nil
+}
+
+func (t DevVersionTemplate) GitShortSha() (string, error) {
+	sha, err := t.GitSha()
+	if err != nil {
+		return "", err
+	}
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return sha, nil
+}
+
 func fmtError(err error, msg string) error {
 	type ErrTemp struct {
 		Error  string
@@ -64,3 +122,47 @@ func createDockerfile(fileName, dockerImage string) (string, error) {
 	}
 	return dockerFileBuild, nil
 }
+
+//renderChangelog renders section of a release by template of target, or the default one.
+//Template is read through git client, then pump reads it from the cloned repository as other files of version
+func renderChangelog(gitClient *core.GitClient, target pumpTarget, changelog core.Changelog) ([]byte, error) {
+	text := DefaultChangelogTemplate
+	if !utils.IsStringEmpty(target.ChangelogTemplate) {
+		file := path.Clean(filepath.ToSlash(utils.Trim(target.ChangelogTemplate)))
+		data, err := gitClient.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read changelog template get error %v", err)
+		}
+		if data == nil {
+			return nil, fmt.Errorf("changelog template %s not found", file)
+		}
+		text = string(data)
+	}
+	t, err := template.New("changelog").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse changelog template get error %v", err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, changelog)
+	if err != nil {
+		return nil, fmt.Errorf("render changelog get error %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+//renderText renders template which is configured as text, default one is used if it is empty
+func renderText(name, text, defaultText string, data interface{}) (string, error) {
+	if utils.IsStringEmpty(text) {
+		text = defaultText
+	}
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse %s template get error %v", name, err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("render %s template get error %v", name, err)
+	}
+	return buf.String(), nil
+}
@@ -3,36 +3,34 @@ package buildpack
 import (
 	"github.com/locngoxuan/buildpack/config"
 	"github.com/locngoxuan/buildpack/core"
+	"github.com/locngoxuan/buildpack/utils"
 )
 
-func aggregateDockerConfigInfo(global config.DockerGlobalConfig) ([]string, []config.DockerRegistry) {
+//aggregateDockerConfigInfo returns hosts and registries of docker config, default ones are included.
+//Global config is already merged into project config by resolver, only clean takes global config since it runs without project
+func aggregateDockerConfigInfo(docker config.DockerConfig) ([]string, []config.DockerRegistry) {
 	if arg.BuildLocal {
 		return []string{}, []config.DockerRegistry{}
 	}
 	hostSet := make(map[string]struct{})
 	hostSet[core.DefaultDockerUnixSock] = struct{}{}
 	hostSet[core.DefaultDockerTCPSock] = struct{}{}
-	if len(global.Hosts) > 0 {
-		for _, host := range global.Hosts {
-			hostSet[host] = struct{}{}
-		}
-	}
-	if len(cfg.DockerConfig.Hosts) > 0 {
-		for _, host := range cfg.DockerConfig.Hosts {
+	if len(docker.Hosts) > 0 {
+		for _, host := range docker.Hosts {
 			hostSet[host] = struct{}{}
 		}
 	}
 
 	registryMap := make(map[string]config.DockerRegistry)
 
-	if len(global.Registries) > 0 {
-		for _, registry := range global.Registries {
-			registryMap[registry.Address] = registry
-		}
-	}
-	if len(cfg.DockerConfig.Registries) > 0 {
-		for _, registry := range cfg.DockerConfig.Registries {
-			registryMap[registry.Address] = registry
+	//registries are kept by id since different ids may share an address, e.g. with different credentials
+	if len(docker.Registries) > 0 {
+		for _, registry := range docker.Registries {
+			key := registry.Id
+			if utils.IsStringEmpty(key) {
+				key = registry.Address
+			}
+			registryMap[key] = registry
 		}
 	}
 
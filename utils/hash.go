@@ -2,13 +2,14 @@ package utils
 
 import (
 	"crypto/md5"
+	"crypto/sha1"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 )
 
-func SumContentMD5(file string) (string, error) {
-	hasher := md5.New()
+func sumContent(file string, hasher hash.Hash) (string, error) {
 	f, err := os.Open(file)
 	if err != nil {
 		return "", err
@@ -22,3 +23,10 @@ func SumContentMD5(file string) (string, error) {
 	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
 }
 
+func SumContentMD5(file string) (string, error) {
+	return sumContent(file, md5.New())
+}
+
+func SumContentSHA1(file string) (string, error) {
+	return sumContent(file, sha1.New())
+}